@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kostage/cosmos_voter/internal/config"
+	"github.com/kostage/cosmos_voter/internal/policy"
+)
+
+func newConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the config file",
+	}
+	validateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the config file the way serve reads it without connecting anywhere",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := config.ParseConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if err := validateConfig(conf); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s is valid\n", configPath)
+			return nil
+		},
+	}
+	configCmd.AddCommand(validateCmd)
+	return configCmd
+}
+
+// validateConfig builds what serve builds except the messenger, which connects
+// on creation, settings themselves are validated by config.ParseConfig
+func validateConfig(conf *config.Config) error {
+	if _, err := newChains(conf); err != nil {
+		return err
+	}
+	if _, err := newPrincipals(conf); err != nil {
+		return err
+	}
+	if conf.PolicyPath != "" {
+		if _, err := policy.LoadPolicy(conf.PolicyPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,37 +2,166 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
 
 	"github.com/kostage/cosmos_voter/internal/app"
 	"github.com/kostage/cosmos_voter/internal/config"
+	"github.com/kostage/cosmos_voter/internal/discordbot"
+	"github.com/kostage/cosmos_voter/internal/matrixbot"
+	"github.com/kostage/cosmos_voter/internal/slackbot"
 	"github.com/kostage/cosmos_voter/internal/tgbot"
 	"github.com/kostage/cosmos_voter/internal/vote"
 )
 
 const (
-	configFile = "config.yaml"
+	defaultConfigFile = "config.yaml"
+)
+
+var (
+	configPath string
 )
 
 func main() {
-	conf, err := config.ParseConfig(configFile)
+	rootCmd := &cobra.Command{
+		Use:   "cosmos_voter",
+		Short: "Vote on cosmos governance proposals from a messenger or the shell",
+		// errors are already printed by cobra, usage only helps with invalid arguments
+		SilenceUsage: true,
+	}
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", defaultConfigFile, "config file path")
+	rootCmd.AddCommand(
+		newServeCmd(),
+		newProposalsCmd(),
+		newVoteCmd(),
+		newStatusCmd(),
+		newConfigCmd(),
+	)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
 	if err != nil {
-		log.Fatal(err)
+		os.Exit(1)
+	}
+}
+
+// newChains builds voters of all configured chains
+func newChains(conf *config.Config) ([]app.Chain, error) {
+	chains := []app.Chain{}
+	for _, chainConf := range conf.AllChains() {
+		voter, err := newVoter(chainConf)
+		if err != nil {
+			return nil, err
+		}
+		chain := app.Chain{
+			ID:             chainConf.ChainId,
+			Name:           chainConf.DisplayName,
+			Voter:          voter,
+			FallbackBefore: chainConf.FallbackBefore,
+		}
+		if chainConf.FallbackVote != "" {
+			if chain.FallbackVote, err = vote.ParseVoteOption(chainConf.FallbackVote); err != nil {
+				return nil, fmt.Errorf("invalid fallback vote of chain %s: %v", chainConf.ChainId, err)
+			}
+		}
+		chains = append(chains, chain)
 	}
-	bot, err := tgbot.NewTgBot(conf.BotToken)
+	return chains, nil
+}
+
+// selectChain returns the chain with chainID, which may be omitted if only one chain is configured
+func selectChain(chains []app.Chain, chainID string) (app.Chain, error) {
+	if chainID == "" {
+		if len(chains) != 1 {
+			return app.Chain{}, fmt.Errorf("%d chains are configured, choose one with --chain", len(chains))
+		}
+		return chains[0], nil
+	}
+	for _, chain := range chains {
+		if chain.ID == chainID {
+			return chain, nil
+		}
+	}
+	return app.Chain{}, fmt.Errorf("chain '%s' is not configured", chainID)
+}
+
+func newPrincipals(conf *config.Config) ([]app.Principal, error) {
+	principals := []app.Principal{}
+	for _, user := range conf.Users {
+		role, err := app.ParseRole(user.Role)
+		if err != nil {
+			return nil, fmt.Errorf("invalid role of user %s: %v", user.ID, err)
+		}
+		principals = append(principals, app.Principal{ID: user.ID, Name: user.Name, Role: role})
+	}
+	return principals, nil
+}
+
+func newVoter(conf config.ChainConfig) (vote.Voter, error) {
+	voter, err := newCosmosVoter(conf)
 	if err != nil {
-		log.Fatal(err)
-	}
-	voter := vote.NewCosmosVoter(
-		conf.DaemonPath,
-		conf.KeyChainPass,
-		conf.VoterWallet,
-		conf.Fees,
-		conf.ChainId,
-	)
-	app := app.NewApp(voter, bot, conf.AllowedUser)
-	if err := app.Run(context.TODO()); err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	if conf.AuthzGranter != "" {
+		voter.UseAuthz(conf.AuthzGranter)
+	}
+	if conf.GasPrices != "" {
+		if err := voter.UseGasPrices(conf.GasPrices, conf.GasAdjustment, conf.MaxFee); err != nil {
+			return nil, fmt.Errorf("chain %s: %v", conf.ChainId, err)
+		}
+	}
+	return voter, nil
+}
+
+func newCosmosVoter(conf config.ChainConfig) (*vote.CosmosVoter, error) {
+	switch conf.QueryBackend {
+	case "", config.QueryBackendCli:
+		return vote.NewCosmosVoter(
+			conf.DaemonPath,
+			conf.KeyChainPass,
+			conf.VoterWallet,
+			conf.Fees,
+			conf.ChainId,
+		), nil
+	case config.QueryBackendLcd:
+		return vote.NewCosmosLcdVoter(
+			conf.LcdURL,
+			conf.DaemonPath,
+			conf.KeyChainPass,
+			conf.VoterWallet,
+			conf.Fees,
+			conf.ChainId,
+		), nil
+	case config.QueryBackendGrpc:
+		return vote.NewCosmosGrpcVoter(
+			conf.GrpcAddr,
+			conf.GrpcTLS,
+			conf.DaemonPath,
+			conf.KeyChainPass,
+			conf.VoterWallet,
+			conf.Fees,
+			conf.ChainId,
+		)
+	default:
+		return nil, fmt.Errorf("unknown query backend '%s' of chain %s", conf.QueryBackend, conf.ChainId)
+	}
+}
+
+func newMessenger(conf *config.Config) (app.Messenger, error) {
+	switch conf.Messenger {
+	case "", config.MessengerTelegram:
+		return tgbot.NewTgBot(conf.BotToken)
+	case config.MessengerSlack:
+		return slackbot.NewSlackBot(conf.Slack.BotToken, conf.Slack.SigningSecret, conf.Slack.ListenAddr), nil
+	case config.MessengerDiscord:
+		return discordbot.NewDiscordBot(conf.Discord.BotToken, conf.Discord.PublicKey, conf.Discord.ListenAddr)
+	case config.MessengerMatrix:
+		return matrixbot.NewMatrixBot(conf.Matrix.HomeserverURL, conf.Matrix.AccessToken, conf.Matrix.UserID), nil
+	default:
+		return nil, fmt.Errorf("unknown messenger '%s'", conf.Messenger)
 	}
 }
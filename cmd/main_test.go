@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kostage/cosmos_voter/internal/app"
+	"github.com/kostage/cosmos_voter/internal/vote"
+)
+
+func TestConfirm(t *testing.T) {
+	out := &bytes.Buffer{}
+	confirmed, err := confirm(strings.NewReader("maybe\ny\n"), out, "Vote yes?")
+	require.NoError(t, err)
+	assert.True(t, confirmed)
+	assert.Equal(t, "Vote yes? [y/N] Vote yes? [y/N] ", out.String())
+
+	confirmed, err = confirm(strings.NewReader("\n"), out, "Vote yes?")
+	require.NoError(t, err)
+	assert.False(t, confirmed)
+	confirmed, err = confirm(strings.NewReader(""), out, "Vote yes?")
+	require.NoError(t, err)
+	assert.False(t, confirmed)
+}
+
+func TestSelectChain(t *testing.T) {
+	kujira := app.Chain{ID: "kaiyo-1"}
+	osmosis := app.Chain{ID: "osmosis-1"}
+	chain, err := selectChain([]app.Chain{kujira}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "kaiyo-1", chain.ID)
+	_, err = selectChain([]app.Chain{kujira, osmosis}, "")
+	assert.ErrorContains(t, err, "--chain")
+	chain, err = selectChain([]app.Chain{kujira, osmosis}, "osmosis-1")
+	require.NoError(t, err)
+	assert.Equal(t, "osmosis-1", chain.ID)
+	_, err = selectChain([]app.Chain{kujira, osmosis}, "cosmoshub-4")
+	assert.Error(t, err)
+}
+
+func TestPrintStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	voter := vote.NewMockVoter(ctrl)
+	chain := app.Chain{ID: "kaiyo-1", Name: "Kujira", Voter: voter}
+	// voted proposals are left out of GetVoting, so status must not rely on it
+	voter.EXPECT().HasVoted(gomock.Any(), "291").Return(true, nil)
+	voter.EXPECT().Tally(gomock.Any(), "291").Return(&vote.Tally{VotedYes: 71.5, VotedNo: 3, Voted: 45, Quorum: 33.4, WouldPass: true}, nil)
+	out := &bytes.Buffer{}
+	require.NoError(t, printStatus(context.Background(), out, chain, "291"))
+	assert.Contains(t, out.String(), "Kujira proposal 291: voted\n")
+	assert.Contains(t, out.String(), "Voted Yes: 71.50 %")
+	assert.Contains(t, out.String(), "Would pass now: yes")
+
+	voter.EXPECT().HasVoted(gomock.Any(), "290").Return(false, nil)
+	voter.EXPECT().Tally(gomock.Any(), "290").Return(nil, fmt.Errorf("proposal 290 doesn't exist"))
+	out.Reset()
+	assert.ErrorContains(t, printStatus(context.Background(), out, chain, "290"), "proposal 290 doesn't exist")
+	assert.Equal(t, "Kujira proposal 290: not voted\n", out.String())
+}
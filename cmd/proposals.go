@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kostage/cosmos_voter/internal/app"
+	"github.com/kostage/cosmos_voter/internal/config"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+
+	// cli commands query the chain once
+	queryTimeout = time.Second * 30
+)
+
+// listedProposal is a proposal of 'proposals list -o json'
+type listedProposal struct {
+	ChainID       string    `json:"chain_id"`
+	ID            string    `json:"id"`
+	Title         string    `json:"title"`
+	VotingEndTime time.Time `json:"voting_end_time"`
+	DeadlineHrs   float64   `json:"deadline_hrs"`
+	VotedYes      float64   `json:"voted_yes"`
+	VotedNo       float64   `json:"voted_no"`
+	Veto          float64   `json:"veto"`
+	Voted         float64   `json:"voted"`
+	Quorum        float64   `json:"quorum"`
+	Threshold     float64   `json:"threshold"`
+	VetoThreshold float64   `json:"veto_threshold"`
+	WouldPass     bool      `json:"would_pass"`
+}
+
+func newProposalsCmd() *cobra.Command {
+	proposalsCmd := &cobra.Command{
+		Use:   "proposals",
+		Short: "Query governance proposals",
+	}
+	chainID := ""
+	output := outputTable
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List proposals in voting period",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != outputTable && output != outputJSON {
+				return fmt.Errorf("output is not [%s|%s]", outputTable, outputJSON)
+			}
+			conf, err := config.ParseConfig(configPath)
+			if err != nil {
+				return err
+			}
+			chains, err := newChains(conf)
+			if err != nil {
+				return err
+			}
+			if chainID != "" {
+				chain, err := selectChain(chains, chainID)
+				if err != nil {
+					return err
+				}
+				chains = []app.Chain{chain}
+			}
+			proposals, err := listProposals(cmd.Context(), chains)
+			if err != nil {
+				return err
+			}
+			if output == outputJSON {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(proposals)
+			}
+			return printProposals(cmd.OutOrStdout(), proposals)
+		},
+	}
+	listCmd.Flags().StringVar(&chainID, "chain", "", "chain id, all chains if empty")
+	listCmd.Flags().StringVarP(&output, "output", "o", outputTable, "output format: table or json")
+	proposalsCmd.AddCommand(listCmd)
+	return proposalsCmd
+}
+
+func listProposals(ctx context.Context, chains []app.Chain) ([]listedProposal, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	result := []listedProposal{}
+	for _, chain := range chains {
+		proposals, err := chain.Voter.GetVoting(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s proposals: %v", chain.ID, err)
+		}
+		for _, prop := range proposals {
+			result = append(result, listedProposal{
+				ChainID:       chain.ID,
+				ID:            prop.Id,
+				Title:         prop.Title,
+				VotingEndTime: prop.VotingEndTime,
+				DeadlineHrs:   prop.DeadlineHrs,
+				VotedYes:      prop.VotedYes,
+				VotedNo:       prop.VotedNo,
+				Veto:          prop.Veto,
+				Voted:         prop.Voted,
+				Quorum:        prop.Quorum,
+				Threshold:     prop.Threshold,
+				VetoThreshold: prop.VetoThreshold,
+				WouldPass:     prop.WouldPass,
+			})
+		}
+	}
+	return result, nil
+}
+
+func printProposals(out io.Writer, proposals []listedProposal) error {
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "CHAIN\tID\tTITLE\tVOTING END\tYES %\tNO %\tVETO %\tVOTED %\tPASSES")
+	for _, prop := range proposals {
+		passes := "no"
+		if prop.WouldPass {
+			passes = "yes"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%.2f\t%.2f\t%.2f\t%.2f\t%s\n",
+			prop.ChainID, prop.ID, prop.Title, prop.VotingEndTime.UTC().Format("2006-01-02 15:04"),
+			prop.VotedYes, prop.VotedNo, prop.Veto, prop.Voted, passes)
+	}
+	return table.Flush()
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/kostage/cosmos_voter/internal/app"
+	"github.com/kostage/cosmos_voter/internal/config"
+	"github.com/kostage/cosmos_voter/internal/mailer"
+	"github.com/kostage/cosmos_voter/internal/policy"
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/kostage/cosmos_voter/internal/webhook"
+)
+
+func newServeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Run the bot with proposal polling, reminders and fallback votes",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := config.ParseConfig(configPath)
+			if err != nil {
+				return err
+			}
+			return serve(cmd.Context(), conf)
+		},
+	}
+}
+
+func serve(ctx context.Context, conf *config.Config) error {
+	messenger, err := newMessenger(conf)
+	if err != nil {
+		return err
+	}
+	chains, err := newChains(conf)
+	if err != nil {
+		return err
+	}
+	var st store.Store = store.NewMemStore()
+	if conf.StorePath != "" {
+		st, err = store.NewBoltStore(conf.StorePath)
+		if err != nil {
+			return err
+		}
+	} else {
+		log.Warn("store_path is not set, state will be lost on restart")
+	}
+	defer st.Close()
+	principals, err := newPrincipals(conf)
+	if err != nil {
+		return err
+	}
+	voterApp := app.NewApp(chains, messenger, principals, st)
+	voterApp.SetApprovers(conf.Approvers, conf.RequiredApprovals)
+	if conf.CallbackSecret != "" {
+		voterApp.SetCallbackSecret(conf.CallbackSecret)
+	} else {
+		log.Warn("callback_secret is not set, vote buttons will stop working on restart")
+	}
+	if conf.PolicyPath != "" {
+		votePolicy, err := policy.LoadPolicy(conf.PolicyPath)
+		if err != nil {
+			return err
+		}
+		voterApp.SetPolicy(votePolicy, conf.PolicyDryRun)
+	}
+	if len(conf.Webhook.URLs) > 0 {
+		if conf.Webhook.Secret == "" {
+			log.Warn("webhook secret is not set, webhook events will be signed with an empty key")
+		}
+		hook := webhook.NewWebhook(conf.Webhook.URLs, conf.Webhook.Secret, conf.Webhook.DeadLetterFile, conf.Webhook.MaxAttempts)
+		voterApp.AddNotifier(hook)
+		go func() {
+			if err := hook.Run(ctx); err != nil {
+				log.Errorf("webhook stopped: %v", err)
+			}
+		}()
+	}
+	if conf.Email.SMTPAddr != "" && len(conf.Email.To) > 0 {
+		smtpMailer := mailer.NewSMTPMailer(conf.Email.SMTPAddr, conf.Email.Username, conf.Email.Password, conf.Email.From, conf.Email.To)
+		if conf.Email.DailyDigest {
+			digest := app.NewDigest(voterApp, smtpMailer, conf.Email.DigestAt)
+			go func() {
+				if err := digest.Run(ctx); err != nil {
+					log.Errorf("digest stopped: %v", err)
+				}
+			}()
+		}
+		if conf.Email.NewProposals {
+			proposalMailer := app.NewProposalMailer(smtpMailer)
+			voterApp.AddNotifier(proposalMailer)
+			go func() {
+				if err := proposalMailer.Run(ctx); err != nil {
+					log.Errorf("proposal mailer stopped: %v", err)
+				}
+			}()
+		}
+	}
+	voterApp.CheckGrants(ctx, conf.ChatID)
+	if conf.ChatID != "" && conf.PollInterval > 0 {
+		poller := app.NewPoller(voterApp, conf.ChatID, conf.PollInterval)
+		go func() {
+			if err := poller.Run(ctx); err != nil {
+				log.Errorf("poller stopped: %v", err)
+			}
+		}()
+	}
+	if conf.ChatID != "" && conf.PollInterval > 0 && len(conf.ReminderThresholds) > 0 {
+		reminder := app.NewReminder(voterApp, conf.ChatID, conf.PollInterval, conf.ReminderThresholds)
+		go func() {
+			if err := reminder.Run(ctx); err != nil {
+				log.Errorf("reminder stopped: %v", err)
+			}
+		}()
+	}
+	if conf.ChatID != "" && conf.PollInterval > 0 && conf.LowBalanceVotes > 0 {
+		balanceMonitor := app.NewBalanceMonitor(voterApp, conf.ChatID, conf.PollInterval, conf.LowBalanceVotes)
+		go func() {
+			if err := balanceMonitor.Run(ctx); err != nil {
+				log.Errorf("balance monitor stopped: %v", err)
+			}
+		}()
+	}
+	if conf.ChatID != "" && conf.PollInterval > 0 {
+		fallbackVoter := app.NewFallbackVoter(voterApp, conf.ChatID, conf.PollInterval)
+		go func() {
+			if err := fallbackVoter.Run(ctx); err != nil {
+				log.Errorf("fallback voter stopped: %v", err)
+			}
+		}()
+	}
+	return voterApp.Run(ctx)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kostage/cosmos_voter/internal/app"
+	"github.com/kostage/cosmos_voter/internal/config"
+)
+
+func newStatusCmd() *cobra.Command {
+	chainID := ""
+	statusCmd := &cobra.Command{
+		Use:   "status <proposal id>",
+		Short: "Show whether the voter wallet voted on a proposal and its tally",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := config.ParseConfig(configPath)
+			if err != nil {
+				return err
+			}
+			chains, err := newChains(conf)
+			if err != nil {
+				return err
+			}
+			chain, err := selectChain(chains, chainID)
+			if err != nil {
+				return err
+			}
+			return printStatus(cmd.Context(), cmd.OutOrStdout(), chain, args[0])
+		},
+	}
+	statusCmd.Flags().StringVar(&chainID, "chain", "", "chain id, may be omitted if one chain is configured")
+	return statusCmd
+}
+
+// printStatus reports the vote of the wallet and the tally of proposal
+func printStatus(ctx context.Context, out io.Writer, chain app.Chain, propID string) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	voted, err := chain.Voter.HasVoted(ctx, propID)
+	if err != nil {
+		return fmt.Errorf("failed to check vote on %s proposal %s: %v", chain.ID, propID, err)
+	}
+	votedStr := "not voted"
+	if voted {
+		votedStr = "voted"
+	}
+	fmt.Fprintf(out, "%s proposal %s: %s\n", chain.Name, propID, votedStr)
+	tally, err := chain.Voter.Tally(ctx, propID)
+	if err != nil {
+		return fmt.Errorf("failed to get tally of %s proposal %s: %v", chain.ID, propID, err)
+	}
+	passes := "no"
+	if tally.WouldPass {
+		passes = "yes"
+	}
+	fmt.Fprintf(out, "Voted Yes: %.2f %%, No: %.2f %%, Veto: %.2f %%\n", tally.VotedYes, tally.VotedNo, tally.Veto)
+	fmt.Fprintf(out, "Voted: %.2f %% of bonded, quorum %.2f %%\n", tally.Voted, tally.Quorum)
+	fmt.Fprintf(out, "Threshold: %.2f %%, veto threshold: %.2f %%\n", tally.Threshold, tally.VetoThreshold)
+	fmt.Fprintf(out, "Would pass now: %s\n", passes)
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kostage/cosmos_voter/internal/app"
+	"github.com/kostage/cosmos_voter/internal/config"
+	"github.com/kostage/cosmos_voter/internal/vote"
+)
+
+func newVoteCmd() *cobra.Command {
+	chainID := ""
+	yes := false
+	voteCmd := &cobra.Command{
+		Use:   "vote <proposal id> <option>",
+		Short: "Vote on a proposal from the voter wallet",
+		Long: "Vote yes, no, abstain or no_with_veto on a proposal, or split the vote with weighted\n" +
+			"options like yes=0.7,abstain=0.3, the vote is confirmed interactively unless --yes is set",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := config.ParseConfig(configPath)
+			if err != nil {
+				return err
+			}
+			chains, err := newChains(conf)
+			if err != nil {
+				return err
+			}
+			chain, err := selectChain(chains, chainID)
+			if err != nil {
+				return err
+			}
+			propID, optionStr := args[0], args[1]
+			if !yes {
+				confirmed, err := confirm(cmd.InOrStdin(), cmd.OutOrStdout(),
+					fmt.Sprintf("Vote %s on %s proposal %s?", optionStr, chain.Name, propID))
+				if err != nil {
+					return err
+				}
+				if !confirmed {
+					fmt.Fprintln(cmd.OutOrStdout(), "Vote cancelled")
+					return nil
+				}
+			}
+			result, err := castVote(cmd.Context(), chain, propID, optionStr)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Voted %s on %s proposal %s\n%s\n", optionStr, chain.Name, propID, result)
+			return nil
+		},
+	}
+	voteCmd.Flags().StringVar(&chainID, "chain", "", "chain id, may be omitted if one chain is configured")
+	voteCmd.Flags().BoolVarP(&yes, "yes", "y", false, "vote without confirmation")
+	return voteCmd
+}
+
+// castVote votes the option or weighted options like yes=0.7,abstain=0.3
+func castVote(ctx context.Context, chain app.Chain, propID string, optionStr string) (*vote.VoteResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, app.VoteTimeout)
+	defer cancel()
+	if strings.Contains(optionStr, "=") {
+		options, err := vote.ParseWeightedVoteOptions(optionStr)
+		if err != nil {
+			return nil, err
+		}
+		return chain.Voter.WeightedVote(ctx, propID, options)
+	}
+	option, err := vote.ParseVoteOption(optionStr)
+	if err != nil {
+		return nil, err
+	}
+	return chain.Voter.Vote(ctx, propID, option)
+}
+
+// confirm asks question until y or n is answered, end of input declines
+func confirm(in io.Reader, out io.Writer, question string) (bool, error) {
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "%s [y/N] ", question)
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return false, scanner.Err()
+		}
+		switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+		case "y", "yes":
+			return true, nil
+		case "", "n", "no":
+			return false, nil
+		}
+	}
+}
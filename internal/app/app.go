@@ -3,12 +3,16 @@ package app
 import (
 	"bytes"
 	"context"
+	_ "embed"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"text/template"
 	"time"
 
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
-	"github.com/kostage/cosmos_voter/internal/tgbot"
+	"github.com/kostage/cosmos_voter/internal/policy"
+	"github.com/kostage/cosmos_voter/internal/store"
 	"github.com/kostage/cosmos_voter/internal/vote"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -16,178 +20,420 @@ import (
 
 const (
 	cmdTimeout = time.Second * 15
+	// VoteTimeout bounds a vote, which waits for the tx to be included in a block
+	VoteTimeout = time.Minute * 2
+	// buttons validity when voting end time of a proposal is unknown
+	unknownVotingEndTTL = 14 * 24 * time.Hour
 
-	votePromptTmplFile = "internal/app/votePrompt.tmpl"
-	voteButtonData     = "vote %s on %s"
+	// vote actions are single letters to fit signed callback data
+	skipAction = "s"
 )
 
 var (
-	votePromptTmpl = template.Must(template.ParseFiles(votePromptTmplFile))
+	//go:embed votePrompt.tmpl
+	votePromptTmplText string
+	votePromptTmpl     = template.Must(template.New("votePrompt").Parse(votePromptTmplText))
+
+	voteButtons = []struct {
+		text   string
+		action string
+	}{
+		{"Yes", "y"},
+		{"No", "n"},
+		{"Abstain", "a"},
+		{"Veto", "v"},
+		{"Skip", skipAction},
+	}
+	voteActions = map[string]vote.VoteOption{
+		"y": vote.VoteOptionYes,
+		"n": vote.VoteOptionNo,
+		"a": vote.VoteOptionAbstain,
+		"v": vote.VoteOptionNoWithVeto,
+	}
 )
 
+// Chain routes prompts and votes to the voter of a chain
+type Chain struct {
+	// chain id, part of callback data
+	ID string
+	// name shown in prompts
+	Name  string
+	Voter vote.Voter
+	// cast FallbackBefore voting end if nobody voted, disabled if unspecified
+	FallbackVote   vote.VoteOption
+	FallbackBefore time.Duration
+}
+
+// votePrompt is votePrompt.tmpl data
+type votePrompt struct {
+	Chain string
+	vote.Proposal
+	// empty if unknown
+	Fee string
+}
+
 type App struct {
-	voter    vote.Voter
-	bot      *tgbot.TgBot
-	username string
+	chains    []Chain
+	messenger Messenger
+	// users allowed to use the bot by messenger user id
+	principals map[string]Principal
+	store      store.Store
+
+	// button presses of approvers are collected until requiredApprovals agree
+	approvers         []string
+	requiredApprovals int
+
+	// auto-vote rules, nil prompts every proposal
+	policy *policy.Policy
+	// switched by admins while pollers read it
+	policyDryRun atomic.Bool
+
+	// signs button callback data
+	callbackSecret []byte
+
+	// weighted votes waiting for confirmation, by prompt nonce
+	pendingWeightedMtx sync.Mutex
+	pendingWeighted    map[string]vote.WeightedVoteOptions
+
+	// report governance events besides the chat
+	notifiers []Notifier
+
+	// proposals with a vote being cast, by chain id and proposal id
+	submittingMtx sync.Mutex
+	submitting    map[string]bool
+	// votes cast off the receive loop
+	submissions sync.WaitGroup
 }
 
-func NewApp(voter vote.Voter, bot *tgbot.TgBot, username string) *App {
+func NewApp(chains []Chain, messenger Messenger, principals []Principal, store store.Store) *App {
+	principalsByID := make(map[string]Principal, len(principals))
+	for _, principal := range principals {
+		principalsByID[principal.ID] = principal
+	}
 	return &App{
-		voter:    voter,
-		bot:      bot,
-		username: username,
+		chains:            chains,
+		messenger:         messenger,
+		principals:        principalsByID,
+		store:             store,
+		requiredApprovals: 1,
+		callbackSecret:    newCallbackSecret(),
+		pendingWeighted:   make(map[string]vote.WeightedVoteOptions),
+		submitting:        make(map[string]bool),
+	}
+}
+
+// SetApprovers makes votes wait for required approvers choosing the same option,
+// any voter may approve if approvers are empty
+func (app *App) SetApprovers(approvers []string, required int) {
+	app.approvers = approvers
+	app.requiredApprovals = required
+	if app.requiredApprovals < 1 {
+		app.requiredApprovals = 1
 	}
 }
 
+// SetCallbackSecret replaces the random secret signing buttons, so that
+// buttons sent before restart keep working
+func (app *App) SetCallbackSecret(secret string) {
+	app.callbackSecret = []byte(secret)
+}
+
+// SetPolicy makes the app vote on proposals matching policy rules without prompting,
+// in dry run mode the app only reports what it would have voted
+func (app *App) SetPolicy(p *policy.Policy, dryRun bool) {
+	app.policy = p
+	app.policyDryRun.Store(dryRun)
+}
+
+func (app *App) chain(id string) (Chain, error) {
+	for _, chain := range app.chains {
+		if chain.ID == id {
+			return chain, nil
+		}
+	}
+	return Chain{}, fmt.Errorf("unknown chain '%s'", id)
+}
+
+// Run handles events until ctx is done, failures to handle an event, like a
+// messenger rejecting an edit, are logged without stopping the bot
 func (app *App) Run(ctx context.Context) error {
-	return app.bot.ProcessUpdates(
+	defer app.submissions.Wait()
+	return app.messenger.Receive(
 		ctx,
-		func(update tgbotapi.Update) error {
-			if update.Message != nil && update.Message.IsCommand() {
-				if err := app.ProcessCommand(ctx, update); err != nil {
-					return errors.Wrapf(err, "failed to process command '%s'", update.Message.Command())
+		func(event Event) error {
+			if event.Command != nil {
+				if err := app.ProcessCommand(ctx, event.Command); err != nil {
+					log.Errorf("failed to process command '%s': %v", event.Command.Name, err)
 				}
 				return nil
 			}
-			if update.CallbackQuery == nil {
+			if event.Press == nil {
 				return nil
 			}
-
-			if err := app.ProcessVoteCallback(ctx, update); err != nil {
-				return errors.Wrapf(err, "failed to process vote callback '%s'", update.CallbackQuery.Data)
+			if err := app.ProcessCallback(ctx, event.Press); err != nil {
+				log.Errorf("failed to process callback '%s': %v", event.Press.Data, err)
 			}
 			return nil
 		},
 	)
 }
 
-func (app *App) ProcessCommand(ctx context.Context, update tgbotapi.Update) error {
-	reportErr := func(err error) error {
-		errText := fmt.Sprintf("Failed to process command '%s', err: %v", update.Message.Command(), err)
-		log.Error(errText)
-		msg := tgbotapi.NewMessage(update.Message.Chat.ID, errText)
-		if _, err := app.bot.BotAPI.Send(msg); err != nil {
-			return errors.Wrapf(err, "failed to send tg message: %v", msg)
-		}
-		return nil
+func (app *App) ProcessCommand(ctx context.Context, cmd *Command) error {
+	log.Infof("received %s", cmd.Name)
+	required, ok := commandRoles[cmd.Name]
+	if !ok {
+		return app.reportCommandErr(cmd, fmt.Errorf("unknown command"))
 	}
-	if update.Message.Command() != "start" {
-		return reportErr(fmt.Errorf("unknown command"))
+	if err := app.authorize(cmd.From, required); err != nil {
+		return app.reportCommandErr(cmd, err)
 	}
-	log.Info("received start")
-	if ok := app.validateUser(update); !ok {
-		return reportErr(fmt.Errorf("unknown user"))
+	switch cmd.Name {
+	case "wvote":
+		return app.ProcessWeightedVoteCommand(ctx, cmd)
+	case "dryrun":
+		return app.ProcessDryRunCommand(cmd)
+	case "balance":
+		return app.ProcessBalanceCommand(ctx, cmd)
 	}
-	ctx, cancel := context.WithTimeout(ctx, cmdTimeout)
-	defer cancel()
-	proposals, err := app.voter.GetVoting(ctx)
-	if err != nil {
-		return reportErr(errors.Wrap(err, "failed to get proposals"))
+	sent := 0
+	for _, chain := range app.chains {
+		queryCtx, cancel := context.WithTimeout(ctx, cmdTimeout)
+		proposals, err := chain.Voter.GetVoting(queryCtx)
+		cancel()
+		if err != nil {
+			return app.reportCommandErr(cmd, errors.Wrapf(err, "failed to get %s proposals", chain.Name))
+		}
+		for _, prop := range proposals {
+			// viewers may start, so policy votes are left to the poller
+			if err := app.SendVotePrompt(ctx, chain, prop, cmd.ChatID); err != nil {
+				log.Errorf("failed to send prompt for %s proposal %s, err: %v", chain.ID, prop.Id, err)
+				return errors.Wrap(err, "failed to send vote prompt")
+			}
+			log.Infof("sent prompt for %s proposal: %s", chain.ID, prop.Id)
+			sent++
+		}
 	}
-	if len(proposals) == 0 {
-		return reportErr(fmt.Errorf("got 0 unvoted proposals"))
+	if sent == 0 {
+		return app.reportCommandErr(cmd, fmt.Errorf("got 0 unvoted proposals"))
 	}
-	for _, prop := range proposals {
-		if err := app.SendVotePrompt(prop, update.Message.Chat.ID); err != nil {
-			log.Errorf("failed to send prompt for proposal %s, err: %v", prop.Id, err)
-			return errors.Wrap(err, "failed to send vote prompt")
+	return nil
+}
+
+// voteKeyboard has a button per gov option plus skip, signed data of buttons
+// is valid till expiry
+func (app *App) voteKeyboard(chainID string, propID string, nonce string, expiry time.Time) ([]Button, error) {
+	buttons := make([]Button, 0, len(voteButtons))
+	for _, button := range voteButtons {
+		data, err := app.signCallback(callbackData{
+			kind:    callbackKindVote,
+			action:  button.action,
+			chainID: chainID,
+			propID:  propID,
+			nonce:   nonce,
+			expiry:  expiry,
+		})
+		if err != nil {
+			return nil, err
 		}
-		log.Infof("sent prompt for proposal: %s", prop.Id)
+		buttons = append(buttons, Button{Text: button.text, Data: data})
 	}
-	return nil
+	return buttons, nil
 }
 
-func (app *App) SendVotePrompt(prop vote.Proposal, chatID int64) error {
-	// Create the keyboard with two buttons
-	yesButton := tgbotapi.NewInlineKeyboardButtonData("Yes", fmt.Sprintf(voteButtonData, "yes", prop.Id))
-	noButton := tgbotapi.NewInlineKeyboardButtonData("No", fmt.Sprintf(voteButtonData, "no", prop.Id))
-	skipButton := tgbotapi.NewInlineKeyboardButtonData("Skip", fmt.Sprintf(voteButtonData, "skip", prop.Id))
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		[]tgbotapi.InlineKeyboardButton{yesButton, noButton, skipButton},
-	)
+func (app *App) SendVotePrompt(ctx context.Context, chain Chain, prop vote.Proposal, chatID string) error {
+	// Buttons are valid until voting ends
+	expiry := prop.VotingEndTime
+	if expiry.IsZero() {
+		expiry = time.Now().Add(unknownVotingEndTTL)
+	}
+	nonce, err := newCallbackNonce()
+	if err != nil {
+		return err
+	}
+	keyboard, err := app.voteKeyboard(chain.ID, prop.Id, nonce, expiry)
+	if err != nil {
+		return errors.Wrap(err, "failed to create vote keyboard")
+	}
 
 	// Send the message to the user
 	promptBuf := &bytes.Buffer{}
-	if err := votePromptTmpl.Execute(promptBuf, prop); err != nil {
+	if err := votePromptTmpl.Execute(promptBuf, votePrompt{
+		Chain:    chain.Name,
+		Proposal: prop,
+		Fee:      app.expectedFee(ctx, chain, prop.Id),
+	}); err != nil {
 		return errors.Wrap(err, "failed to send vote keyboard")
 	}
-	msg := tgbotapi.NewMessage(chatID, promptBuf.String())
-	if _, err := app.bot.BotAPI.Send(msg); err != nil {
+	if _, err := app.messenger.Send(chatID, promptBuf.String(), nil); err != nil {
 		return errors.Wrap(err, "failed to send vote prompt")
 	}
 
 	// Send the keyboard to the user
-	msg = tgbotapi.NewMessage(chatID, "Please vote yes, no or skip for now")
-	msg.ReplyMarkup = keyboard
-	if _, err := app.bot.BotAPI.Send(msg); err != nil {
+	sent, err := app.messenger.Send(chatID, "Please vote yes, no, abstain, no with veto or skip for now", keyboard)
+	if err != nil {
 		return errors.Wrap(err, "failed to send vote keyboard")
 	}
+	app.recordProposal(chain, prop)
+	app.recordPrompt(chain.ID, prop.Id, nonce, sent)
 	return nil
 }
 
-func (app *App) ProcessVoteCallback(ctx context.Context, update tgbotapi.Update) error {
-	reportErr := func(err error) error {
-		errText := fmt.Sprintf("Failed to process callback data '%s', err: %v", update.CallbackQuery.Data, err)
-		msg := tgbotapi.NewEditMessageText(
-			update.CallbackQuery.Message.Chat.ID,
-			update.CallbackQuery.Message.MessageID,
-			errText,
-		)
-		if _, err := app.bot.BotAPI.Send(msg); err != nil {
-			return errors.Wrapf(err, "failed to send tg message '%s'", errText)
+// ProcessVoteCallback votes the option of the pressed button, data is verified by ProcessCallback
+func (app *App) ProcessVoteCallback(ctx context.Context, press *ButtonPress, data callbackData) error {
+	chain, err := app.chain(data.chainID)
+	if err != nil {
+		return app.reportCallbackErr(press, err)
+	}
+	propID := data.propID
+	voteStr := "skip"
+	if option, ok := voteActions[data.action]; ok {
+		voteStr = option.String()
+	}
+	congrat := fmt.Sprintf("You voted %s on %s proposal %s", voteStr, chain.Name, propID)
+	if data.action == skipAction && app.requiredApprovals > 1 {
+		// keep the keyboard for other approvers
+		return app.notifyCallback(press, "Skipped")
+	}
+	if data.action != skipAction {
+		option, ok := voteActions[data.action]
+		if !ok {
+			log.Errorf("vote is not [yes|no|abstain|no_with_veto|skip] in callback '%s'", press.Data)
+			return app.reportCallbackErr(press, fmt.Errorf("vote is not [yes|no|abstain|no_with_veto|skip]"))
 		}
-		callbackAnswer := tgbotapi.NewCallback(update.CallbackQuery.ID, "")
-		if _, err := app.bot.BotAPI.AnswerCallbackQuery(callbackAnswer); err != nil {
-			return errors.Wrap(err, "failed to answer the callback query to remove the 'loading' animation from the button")
+		if !app.isApprover(press.From) {
+			return app.notifyCallback(press, "You are not an approver")
 		}
-		return nil
+		approvers := []string{app.displayName(press.From)}
+		if app.requiredApprovals > 1 {
+			approval, err := app.approve(chain.ID, propID, press.From, option.String())
+			if err != nil {
+				return app.reportCallbackErr(press, err)
+			}
+			if approval.approved == "" {
+				keyboard, err := app.voteKeyboard(chain.ID, propID, data.nonce, data.expiry)
+				if err != nil {
+					return app.reportCallbackErr(press, err)
+				}
+				return app.reportApprovals(press, chain, propID, approval, keyboard)
+			}
+			approvers = approval.byOption[approval.approved]
+			congrat = fmt.Sprintf("Approved by %s, voted %s on %s proposal %s",
+				strings.Join(approvers, ", "), voteStr, chain.Name, propID)
+		}
+		return app.submitVote(ctx, submission{
+			press:     press,
+			data:      data,
+			chain:     chain,
+			option:    option.String(),
+			approvers: approvers,
+			congrat:   congrat,
+			what:      "vote",
+			cast: func(ctx context.Context) (*vote.VoteResult, error) {
+				return chain.Voter.Vote(ctx, propID, option)
+			},
+		})
 	}
-	log.Infof("received callback: %s", update.CallbackQuery.Data)
-	var voteStr string
-	var propID string
-	if _, err := fmt.Sscanf(update.CallbackQuery.Data, voteButtonData, &voteStr, &propID); err != nil {
-		return reportErr(err)
-	}
-	switch voteStr {
-	case "yes":
-	case "no":
-	case "skip":
-	default:
-		log.Errorf("vote is not [yes|no|skip] in callback '%s'", update.CallbackQuery.Data)
-		return reportErr(fmt.Errorf("vote is not [yes|no|skip]"))
-	}
-	if voteStr != "skip" {
-		ctx, cancel := context.WithTimeout(ctx, cmdTimeout)
-		defer cancel()
-		if err := app.voter.Vote(ctx, propID, voteStr); err != nil {
-			return reportErr(errors.Wrap(err, "vote failed"))
+	app.useCallback(data)
+	if err := app.answerCallback(press, congrat); err != nil {
+		return err
+	}
+	log.Infof("skipped %s proposal %s", chain.ID, propID)
+	return nil
+}
+
+// recordPrompt saves the keyboard message, failures are only logged as the prompt
+// is already delivered, though its buttons are rejected then
+func (app *App) recordPrompt(chainID string, propID string, nonce string, msg MessageRef) {
+	err := app.store.AddPrompt(store.PromptRecord{
+		ChainID:    chainID,
+		ProposalID: propID,
+		ChatID:     store.MessengerID(msg.ChatID),
+		MessageID:  store.MessengerID(msg.MessageID),
+		Nonce:      nonce,
+		SentAt:     time.Now().UTC(),
+	})
+	if err != nil {
+		log.Errorf("failed to store prompt for %s proposal %s: %v", chainID, propID, err)
+	}
+}
+
+func (app *App) recordProposal(chain Chain, prop vote.Proposal) {
+	err := app.store.UpdateProposal(chain.ID, prop.Id, func(rec *store.ProposalRecord) error {
+		rec.Title = prop.Title
+		rec.VotingEndTime = prop.VotingEndTime
+		if rec.FirstSeen.IsZero() {
+			rec.FirstSeen = time.Now().UTC()
 		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("failed to store %s proposal %s: %v", chain.ID, prop.Id, err)
 	}
-	congrat := fmt.Sprintf("You voted %s on proposal %s", voteStr, propID)
-	msg := tgbotapi.NewEditMessageText(
-		update.CallbackQuery.Message.Chat.ID,
-		update.CallbackQuery.Message.MessageID,
-		congrat,
-	)
-	if _, err := app.bot.BotAPI.Send(msg); err != nil {
-		return errors.Wrapf(err, "failed to send tg message '%s'", congrat)
+}
+
+// recordVote saves the submitted vote along with the prompt it answers and who approved it
+func (app *App) recordVote(press *ButtonPress, chainID string, propID string, option string, approvedBy []string, txHash string) {
+	err := app.store.AddVote(store.VoteRecord{
+		ChainID:    chainID,
+		ProposalID: propID,
+		Option:     option,
+		TxHash:     txHash,
+		ApprovedBy: approvedBy,
+		ChatID:     store.MessengerID(press.Message.ChatID),
+		MessageID:  store.MessengerID(press.Message.MessageID),
+		Submitted:  time.Now().UTC(),
+	})
+	if err != nil {
+		log.Errorf("failed to store vote on %s proposal %s: %v", chainID, propID, err)
+	}
+}
+
+func (app *App) sendText(chatID string, text string) error {
+	if _, err := app.messenger.Send(chatID, text, nil); err != nil {
+		return errors.Wrapf(err, "failed to send message '%s'", text)
 	}
-	callbackAnswer := tgbotapi.NewCallback(update.CallbackQuery.ID, "")
-	if _, err := app.bot.BotAPI.AnswerCallbackQuery(callbackAnswer); err != nil {
-		return errors.Wrap(err, "failed to answer the callback query to remove the 'loading' animation from the button")
+	return nil
+}
+
+func (app *App) reportCommandErr(cmd *Command, err error) error {
+	errText := fmt.Sprintf("Failed to process command '%s', err: %v", cmd.Name, err)
+	log.Error(errText)
+	if _, err := app.messenger.Send(cmd.ChatID, errText, nil); err != nil {
+		return errors.Wrapf(err, "failed to send message '%s'", errText)
 	}
-	log.Infof("voted %s on proposal %s", voteStr, propID)
 	return nil
 }
 
-func (app *App) validateUser(update tgbotapi.Update) bool {
-	if update.Message.From == nil {
-		log.Error("unknown user")
-		return false
+func (app *App) reportCallbackErr(press *ButtonPress, err error) error {
+	errText := fmt.Sprintf("Failed to process callback data '%s', err: %v", press.Data, err)
+	return app.answerCallback(press, errText)
+}
+
+// voteFailed names the class of tx error and how to fix it, so that voters
+// know whether pressing the button again helps
+func voteFailed(err error, what string) error {
+	var txErr *vote.TxError
+	if errors.As(err, &txErr) && txErr.Class != vote.TxErrorUnknown {
+		return errors.Wrapf(err, "%s failed with %s (%s)", what, txErr.Class, txErr.Class.Hint())
+	}
+	return errors.Wrapf(err, "%s failed", what)
+}
+
+// answerCallback replaces the keyboard message with text and stops the button 'loading' animation
+func (app *App) answerCallback(press *ButtonPress, text string) error {
+	if err := app.messenger.Edit(press.Message, text, nil); err != nil {
+		return errors.Wrapf(err, "failed to edit message with '%s'", text)
 	}
-	if update.Message.From.UserName != app.username {
-		log.Errorf("command from some motherfucker: %s", update.Message.From.UserName)
-		return false
+	if err := app.messenger.Answer(press, ""); err != nil {
+		return errors.Wrap(err, "failed to answer the button press to remove the 'loading' animation from the button")
 	}
-	return true
+	return nil
+}
+
+// notifyCallback shows text to the button presser leaving the message as is
+func (app *App) notifyCallback(press *ButtonPress, text string) error {
+	if err := app.messenger.Answer(press, text); err != nil {
+		return errors.Wrapf(err, "failed to answer the button press with '%s'", text)
+	}
+	return nil
 }
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// approval is the state of approvals on a proposal after a button press
+type approval struct {
+	// display names of approvers by option
+	byOption map[string][]string
+	// option enough approvers agreed on, empty until then
+	approved string
+}
+
+func (a approval) conflict() bool {
+	return len(a.byOption) > 1
+}
+
+func (a approval) String() string {
+	options := make([]string, 0, len(a.byOption))
+	for option := range a.byOption {
+		options = append(options, option)
+	}
+	sort.Strings(options)
+	lines := make([]string, 0, len(options))
+	for _, option := range options {
+		lines = append(lines, fmt.Sprintf("%s: %s", option, strings.Join(a.byOption[option], ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (app *App) isApprover(user *User) bool {
+	if user == nil {
+		return false
+	}
+	if len(app.approvers) == 0 {
+		return true
+	}
+	for _, approver := range app.approvers {
+		if approver == user.ID {
+			return true
+		}
+	}
+	log.Errorf("button pressed by non approver %s", user)
+	return false
+}
+
+// approve records option chosen by approver, an approver may change the choice
+// until enough approvers agree on the same option, approvals are keyed by user
+// id as display names may clash and are kept until clearApprovals
+func (app *App) approve(chainID string, propID string, approver *User, option string) (approval, error) {
+	result := approval{}
+	err := app.store.UpdateProposal(chainID, propID, func(rec *store.ProposalRecord) error {
+		if rec.Approvals == nil {
+			rec.Approvals = make(map[string]string)
+		}
+		rec.Approvals[approver.ID] = option
+		result.byOption = make(map[string][]string)
+		for userID, opt := range rec.Approvals {
+			name := app.approverName(userID)
+			if userID == approver.ID {
+				name = app.displayName(approver)
+			}
+			result.byOption[opt] = append(result.byOption[opt], name)
+		}
+		for _, names := range result.byOption {
+			sort.Strings(names)
+		}
+		if len(result.byOption[option]) >= app.requiredApprovals {
+			result.approved = option
+		}
+		return nil
+	})
+	if err != nil {
+		return result, errors.Wrap(err, "failed to store approval")
+	}
+	log.Infof("%s (id %s) approved %s on %s proposal %s", approver, approver.ID, option, chainID, propID)
+	return result, nil
+}
+
+// clearApprovals resets approvals once the approved vote is cast, failed votes
+// keep them so that an approver can retry
+func (app *App) clearApprovals(chainID string, propID string) {
+	err := app.store.UpdateProposal(chainID, propID, func(rec *store.ProposalRecord) error {
+		rec.Approvals = nil
+		return nil
+	})
+	if err != nil {
+		log.Errorf("failed to clear approvals of %s proposal %s: %v", chainID, propID, err)
+	}
+}
+
+// approverName is the principal name of user id, the id itself if unnamed
+func (app *App) approverName(userID string) string {
+	if principal, ok := app.principals[userID]; ok && principal.Name != "" {
+		return principal.Name
+	}
+	return userID
+}
+
+// reportApprovals shows who approved what in the prompt keeping its keyboard,
+// conflicting choices are also announced to the chat
+func (app *App) reportApprovals(
+	press *ButtonPress,
+	chain Chain,
+	propID string,
+	approval approval,
+	keyboard []Button,
+) error {
+	text := fmt.Sprintf("Approvals on %s proposal %s, %d of the same option needed:\n%s",
+		chain.Name, propID, app.requiredApprovals, approval)
+	if err := app.messenger.Edit(press.Message, text, keyboard); err != nil {
+		return errors.Wrapf(err, "failed to edit message with '%s'", text)
+	}
+	if approval.conflict() {
+		conflict := fmt.Sprintf("Approvers disagree on %s proposal %s:\n%s", chain.Name, propID, approval)
+		if err := app.sendText(press.Message.ChatID, conflict); err != nil {
+			return err
+		}
+	}
+	return app.notifyCallback(press, "Approval recorded")
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	yesButton = 0
+	noButton  = 1
+)
+
+type approvalTest struct {
+	app       *App
+	voter     *vote.MockVoter
+	messenger *fakeMessenger
+	keyboard  sentMessage
+}
+
+// newApprovalTest prompts a vote needing 2 of 3 approvers, mallory is a voter
+// but not an approver
+func newApprovalTest(t *testing.T) *approvalTest {
+	ctrl := gomock.NewController(t)
+	voter := vote.NewMockVoter(ctrl)
+	messenger := &fakeMessenger{}
+	chains := []Chain{{ID: "kaiyo-1", Name: "Kujira", Voter: voter}}
+	principals := []Principal{
+		{ID: "1", Name: "alice", Role: RoleVoter},
+		{ID: "2", Name: "bob", Role: RoleVoter},
+		{ID: "3", Role: RoleVoter},
+		{ID: "4", Name: "mallory", Role: RoleVoter},
+	}
+	app := NewApp(chains, messenger, principals, store.NewMemStore())
+	app.SetApprovers([]string{"1", "2", "3"}, 2)
+	prop := vote.Proposal{Id: "291", Title: "GHOST", VotingEndTime: time.Now().Add(time.Hour)}
+	require.NoError(t, app.SendVotePrompt(context.Background(), chains[0], prop, "-100123"))
+	require.Len(t, messenger.sent, 2)
+	return &approvalTest{app: app, voter: voter, messenger: messenger, keyboard: messenger.sent[1]}
+}
+
+func (at *approvalTest) press(t *testing.T, userID string, name string, button int) {
+	press := &ButtonPress{
+		From:    &User{ID: userID, Name: name},
+		Message: at.keyboard.ref,
+		Data:    at.keyboard.buttons[button].Data,
+	}
+	require.NoError(t, at.app.ProcessCallback(context.Background(), press))
+	at.app.submissions.Wait()
+}
+
+func (at *approvalTest) lastEdit(t *testing.T) string {
+	require.NotEmpty(t, at.messenger.edited)
+	return at.messenger.edited[len(at.messenger.edited)-1].text
+}
+
+func (at *approvalTest) approvals(t *testing.T) map[string]string {
+	rec, err := at.app.store.GetProposal("kaiyo-1", "291")
+	require.NoError(t, err)
+	return rec.Approvals
+}
+
+func TestApprovalQuorum(t *testing.T) {
+	at := newApprovalTest(t)
+	at.press(t, "1", "alice.tg", yesButton)
+	assert.Contains(t, at.lastEdit(t), "yes: alice")
+	assert.Equal(t, map[string]string{"1": "yes"}, at.approvals(t))
+
+	at.voter.EXPECT().Vote(gomock.Any(), "291", vote.VoteOptionYes).Return(&vote.VoteResult{TxHash: "ABCD"}, nil)
+	at.press(t, "2", "bob.tg", yesButton)
+	assert.Contains(t, at.lastEdit(t), "Approved by alice, bob, voted yes on Kujira proposal 291")
+	assert.Empty(t, at.approvals(t))
+	votes, err := at.app.store.ListVotes("kaiyo-1", "291")
+	require.NoError(t, err)
+	require.Len(t, votes, 1)
+	assert.Equal(t, []string{"alice", "bob"}, votes[0].ApprovedBy)
+}
+
+func TestApprovalCountsUserOnce(t *testing.T) {
+	at := newApprovalTest(t)
+	// the mock fails the test on Vote
+	at.press(t, "1", "alice", yesButton)
+	at.press(t, "1", "alice renamed", yesButton)
+	assert.Equal(t, map[string]string{"1": "yes"}, at.approvals(t))
+	// an unnamed approver sharing the messenger name of alice is still someone else
+	at.voter.EXPECT().Vote(gomock.Any(), "291", vote.VoteOptionYes).Return(&vote.VoteResult{TxHash: "ABCD"}, nil)
+	at.press(t, "3", "alice", yesButton)
+	assert.Contains(t, at.lastEdit(t), "Approved by alice, alice, voted yes")
+}
+
+func TestApprovalConflict(t *testing.T) {
+	at := newApprovalTest(t)
+	at.press(t, "1", "alice", yesButton)
+	at.press(t, "2", "bob", noButton)
+	assert.Contains(t, at.lastEdit(t), "no: bob\nyes: alice")
+	assert.Contains(t, at.messenger.sent[len(at.messenger.sent)-1].text, "Approvers disagree on Kujira proposal 291")
+	assert.Equal(t, map[string]string{"1": "yes", "2": "no"}, at.approvals(t))
+	// keyboard stays for changing the choice
+	assert.NotEmpty(t, at.messenger.edited[len(at.messenger.edited)-1].buttons)
+}
+
+func TestApprovalNonApprover(t *testing.T) {
+	at := newApprovalTest(t)
+	at.press(t, "4", "mallory", yesButton)
+	assert.Equal(t, "You are not an approver", at.messenger.answered[len(at.messenger.answered)-1])
+	assert.Empty(t, at.approvals(t))
+}
+
+func TestApprovalKeptOnFailedVote(t *testing.T) {
+	at := newApprovalTest(t)
+	at.press(t, "1", "alice", yesButton)
+	at.voter.EXPECT().Vote(gomock.Any(), "291", vote.VoteOptionYes).Return(nil, errors.New("out of gas"))
+	at.press(t, "2", "bob", yesButton)
+	assert.Contains(t, at.lastEdit(t), "out of gas")
+	assert.Equal(t, map[string]string{"1": "yes", "2": "yes"}, at.approvals(t))
+
+	// either approver retries without collecting approvals again
+	at.voter.EXPECT().Vote(gomock.Any(), "291", vote.VoteOptionYes).Return(&vote.VoteResult{TxHash: "ABCD"}, nil)
+	at.press(t, "1", "alice", yesButton)
+	assert.Contains(t, at.lastEdit(t), "Approved by alice, bob, voted yes")
+	assert.Empty(t, at.approvals(t))
+}
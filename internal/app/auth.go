@@ -0,0 +1,85 @@
+package app
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Role is what a principal may do, each role includes the lower ones
+type Role int
+
+const (
+	RoleNone Role = iota
+	// lists proposals
+	RoleViewer
+	// votes and approves votes
+	RoleVoter
+	// changes settings
+	RoleAdmin
+)
+
+var (
+	roleNames = map[Role]string{
+		RoleNone:   "none",
+		RoleViewer: "viewer",
+		RoleVoter:  "voter",
+		RoleAdmin:  "admin",
+	}
+
+	// role required by each command
+	commandRoles = map[string]Role{
+		"start":   RoleViewer,
+		"balance": RoleViewer,
+		"wvote":   RoleVoter,
+		"dryrun":  RoleAdmin,
+	}
+)
+
+func (r Role) String() string {
+	return roleNames[r]
+}
+
+func ParseRole(s string) (Role, error) {
+	for role, name := range roleNames {
+		if role != RoleNone && s == name {
+			return role, nil
+		}
+	}
+	return RoleNone, fmt.Errorf("unknown role '%s', expected viewer, voter or admin", s)
+}
+
+// Principal is a user allowed to use the bot, identified by the opaque user
+// id of the messenger as names may change
+type Principal struct {
+	ID string
+	// shown in approvals, messenger name if empty
+	Name string
+	Role Role
+}
+
+// authorize checks that user is a principal with at least required role
+func (app *App) authorize(user *User, required Role) error {
+	if user == nil {
+		log.Error("unknown user")
+		return fmt.Errorf("unknown user")
+	}
+	principal, ok := app.principals[user.ID]
+	if !ok {
+		log.Errorf("request from unknown user %s (id %s)", user, user.ID)
+		return fmt.Errorf("unknown user")
+	}
+	if principal.Role < required {
+		log.Errorf("user %s (id %s) with role %s needs role %s", user, user.ID, principal.Role, required)
+		return fmt.Errorf("%s role is required", required)
+	}
+	return nil
+}
+
+// displayName returns configured principal name or messenger name of user
+func (app *App) displayName(user *User) string {
+	if principal, ok := app.principals[user.ID]; ok && principal.Name != "" {
+		return principal.Name
+	}
+	return user.String()
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/vote"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// grants expiring sooner are reported as a warning
+	grantExpiryWarning = 7 * 24 * time.Hour
+)
+
+// CheckGrants reports grants of chains voting through authz to the chat,
+// missing or expired grants make every vote of the chain fail
+func (app *App) CheckGrants(ctx context.Context, chatID string) {
+	for _, chain := range app.chains {
+		authz, ok := chain.Voter.(vote.AuthzVoter)
+		if !ok || authz.Granter() == "" {
+			continue
+		}
+		report := app.checkGrant(ctx, chain, authz)
+		log.Info(report)
+		if chatID == "" {
+			continue
+		}
+		if err := app.sendText(chatID, report); err != nil {
+			log.Errorf("failed to report %s grant: %v", chain.Name, err)
+		}
+	}
+}
+
+func (app *App) checkGrant(ctx context.Context, chain Chain, authz vote.AuthzVoter) string {
+	ctx, cancel := context.WithTimeout(ctx, cmdTimeout)
+	defer cancel()
+	grant, err := authz.CheckGrant(ctx)
+	if err != nil {
+		return fmt.Sprintf("%s votes on behalf of %s will fail: %v", chain.Name, authz.Granter(), err)
+	}
+	if grant.Expiration == nil {
+		return fmt.Sprintf("%s votes are sent by %s on behalf of %s, grant never expires",
+			chain.Name, grant.Grantee, grant.Granter)
+	}
+	expiresIn := time.Until(*grant.Expiration)
+	report := fmt.Sprintf("%s votes are sent by %s on behalf of %s, grant expires at %s",
+		chain.Name, grant.Grantee, grant.Granter, grant.Expiration.UTC().Format(time.RFC3339))
+	if expiresIn < grantExpiryWarning {
+		report = fmt.Sprintf("WARNING: %s, in %s, renew it", report, expiresIn.Round(time.Minute))
+	}
+	return report
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/policy"
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ProposeVote votes on proposal as policy decides and reports it to chat,
+// proposals policy leaves to humans, dry run and failed auto-votes are prompted
+func (app *App) ProposeVote(ctx context.Context, chain Chain, prop vote.Proposal, chatID string) error {
+	if app.policy == nil {
+		return app.SendVotePrompt(ctx, chain, prop, chatID)
+	}
+	decision := app.policy.Decide(chain.ID, prop)
+	if decision.Ask() {
+		return app.SendVotePrompt(ctx, chain, prop, chatID)
+	}
+	if app.policyDryRun.Load() {
+		text := fmt.Sprintf("Dry run: %s would vote %s on %s proposal %s", decision.By(), decision.Option, chain.Name, prop.Id)
+		if err := app.sendText(chatID, text); err != nil {
+			return errors.Wrap(err, "failed to report dry run")
+		}
+		log.Infof("dry run: %s would vote %s on %s proposal %s", decision.By(), decision.Option, chain.ID, prop.Id)
+		return app.SendVotePrompt(ctx, chain, prop, chatID)
+	}
+
+	by := []string{decision.By()}
+	app.notifyVoteSubmitted(chain, prop.Id, decision.Option.String(), by)
+	ctx, cancel := context.WithTimeout(ctx, VoteTimeout)
+	defer cancel()
+	result, err := chain.Voter.Vote(ctx, prop.Id, decision.Option)
+	app.notifyVoteResult(chain, prop.Id, decision.Option.String(), by, result, err)
+	if err != nil {
+		log.Errorf("%s failed to vote %s on %s proposal %s, err: %v", decision.By(), decision.Option, chain.ID, prop.Id, err)
+		text := fmt.Sprintf("Auto-vote %s on %s proposal %s by %s failed: %v", decision.Option, chain.Name, prop.Id, decision.By(), err)
+		if err := app.sendText(chatID, text); err != nil {
+			return errors.Wrap(err, "failed to report auto-vote failure")
+		}
+		return app.SendVotePrompt(ctx, chain, prop, chatID)
+	}
+	app.recordAutoVote(chain, prop, decision, chatID, result.TxHash)
+	log.Infof("%s voted %s on %s proposal %s", decision.By(), decision.Option, chain.ID, prop.Id)
+	text := fmt.Sprintf("Auto-voted %s on %s proposal %s '%s' by %s\n%s",
+		decision.Option, chain.Name, prop.Id, prop.Title, decision.By(), result)
+	if err := app.sendText(chatID, text); err != nil {
+		return errors.Wrap(err, "failed to report auto-vote")
+	}
+	return nil
+}
+
+func (app *App) recordAutoVote(chain Chain, prop vote.Proposal, decision policy.Decision, chatID string, txHash string) {
+	app.recordProposal(chain, prop)
+	err := app.store.AddVote(store.VoteRecord{
+		ChainID:    chain.ID,
+		ProposalID: prop.Id,
+		Option:     decision.Option.String(),
+		TxHash:     txHash,
+		ApprovedBy: []string{decision.By()},
+		ChatID:     store.MessengerID(chatID),
+		Submitted:  time.Now().UTC(),
+	})
+	if err != nil {
+		log.Errorf("failed to store auto-vote on %s proposal %s: %v", chain.ID, prop.Id, err)
+	}
+}
+
+// ProcessDryRunCommand handles '/dryrun <on|off>' switching policy dry run mode
+func (app *App) ProcessDryRunCommand(cmd *Command) error {
+	if app.policy == nil {
+		return app.reportCommandErr(cmd, fmt.Errorf("no vote policy is configured"))
+	}
+	switch cmd.Args {
+	case "on":
+		app.policyDryRun.Store(true)
+	case "off":
+		app.policyDryRun.Store(false)
+	default:
+		return app.reportCommandErr(cmd, fmt.Errorf("usage: /dryrun <on|off>"))
+	}
+	log.Infof("%s switched policy dry run %s", cmd.From, cmd.Args)
+	return app.sendText(cmd.ChatID, fmt.Sprintf("Policy dry run is %s", cmd.Args))
+}
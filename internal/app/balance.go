@@ -0,0 +1,111 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ProcessBalanceCommand handles '/balance' and reports voter wallet balance of each chain
+func (app *App) ProcessBalanceCommand(ctx context.Context, cmd *Command) error {
+	reports := []string{}
+	for _, chain := range app.chains {
+		checker, ok := chain.Voter.(vote.BalanceChecker)
+		if !ok {
+			continue
+		}
+		balance, err := app.balance(ctx, checker)
+		if err != nil {
+			reports = append(reports, fmt.Sprintf("%s: failed to get balance, err: %v", chain.Name, err))
+			continue
+		}
+		reports = append(reports, fmt.Sprintf("%s wallet %s: %s", chain.Name, balance.Wallet, balance))
+	}
+	if len(reports) == 0 {
+		return app.reportCommandErr(cmd, fmt.Errorf("no chain reports balance"))
+	}
+	return app.sendText(cmd.ChatID, strings.Join(reports, "\n"))
+}
+
+func (app *App) balance(ctx context.Context, checker vote.BalanceChecker) (*vote.Balance, error) {
+	ctx, cancel := context.WithTimeout(ctx, cmdTimeout)
+	defer cancel()
+	return checker.Balance(ctx)
+}
+
+// BalanceMonitor periodically warns when voter wallet pays for fewer than
+// minVotes votes, once per drop below
+type BalanceMonitor struct {
+	app      *App
+	chatID   string
+	interval time.Duration
+	minVotes int64
+	// chains reported low, by chain id
+	low map[string]bool
+}
+
+func NewBalanceMonitor(app *App, chatID string, interval time.Duration, minVotes int) *BalanceMonitor {
+	return &BalanceMonitor{
+		app:      app,
+		chatID:   chatID,
+		interval: interval,
+		minVotes: int64(minVotes),
+		low:      map[string]bool{},
+	}
+}
+
+func (m *BalanceMonitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		m.check(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (m *BalanceMonitor) check(ctx context.Context) {
+	for _, chain := range m.app.chains {
+		checker, ok := chain.Voter.(vote.BalanceChecker)
+		if !ok {
+			continue
+		}
+		if err := m.checkChain(ctx, chain, checker); err != nil {
+			log.Errorf("balance monitor failed to check %s: %v", chain.ID, err)
+		}
+	}
+}
+
+func (m *BalanceMonitor) checkChain(ctx context.Context, chain Chain, checker vote.BalanceChecker) error {
+	balance, err := m.app.balance(ctx, checker)
+	if err != nil {
+		return err
+	}
+	low := balance.Votes() >= 0 && balance.Votes() < m.minVotes
+	if !low {
+		if m.low[chain.ID] {
+			log.Infof("%s wallet %s balance is restored: %s", chain.ID, balance.Wallet, balance)
+		}
+		delete(m.low, chain.ID)
+		return nil
+	}
+	if m.low[chain.ID] {
+		return nil
+	}
+	text := fmt.Sprintf("Low balance: %s wallet %s has %s, keep enough for %d votes or votes will fail",
+		chain.Name, balance.Wallet, balance, m.minVotes)
+	if err := m.app.sendText(m.chatID, text); err != nil {
+		return errors.Wrap(err, "failed to send low balance alert")
+	}
+	m.low[chain.ID] = true
+	log.Warn(text)
+	return nil
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/stretchr/testify/assert"
+)
+
+// balanceVoter reports the next of amounts on each balance check
+type balanceVoter struct {
+	*vote.MockVoter
+	amounts []int64
+	voteFee int64
+}
+
+func (v *balanceVoter) Balance(ctx context.Context) (*vote.Balance, error) {
+	amount := v.amounts[0]
+	v.amounts = v.amounts[1:]
+	return &vote.Balance{
+		Wallet:  "kujira1voter",
+		Amount:  big.NewInt(amount),
+		Denom:   "ukuji",
+		VoteFee: big.NewInt(v.voteFee),
+	}, nil
+}
+
+func TestBalanceMonitorAlertsOncePerDrop(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		voteFee int64
+		amounts []int64
+		// alerts sent so far after each check
+		alerts []int
+	}{
+		{"enough", 250, []int64{5000, 2500}, []int{0, 0}},
+		{"low", 250, []int64{2499}, []int{1}},
+		{"still low", 250, []int64{2000, 1000, 0}, []int{1, 1, 1}},
+		{"restored and low again", 250, []int64{1000, 5000, 1000}, []int{1, 1, 2}},
+		{"free votes", 0, []int64{0}, []int{0}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			voter := &balanceVoter{MockVoter: vote.NewMockVoter(gomock.NewController(t)), amounts: tc.amounts, voteFee: tc.voteFee}
+			messenger := &fakeMessenger{}
+			chain := Chain{ID: "kaiyo-1", Name: "Kujira", Voter: voter}
+			app := NewApp([]Chain{chain}, messenger, nil, store.NewMemStore())
+			monitor := NewBalanceMonitor(app, "-100123", time.Minute, 10)
+			for i, alerts := range tc.alerts {
+				monitor.check(context.Background())
+				assert.Len(t, messenger.sent, alerts, "check %d", i+1)
+			}
+			for _, sent := range messenger.sent {
+				assert.Contains(t, sent.text, "Low balance: Kujira wallet kujira1voter")
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	callbackKindVote     = "v"
+	callbackKindWeighted = "w"
+
+	// telegram limit of callback data, the smallest of messengers
+	callbackMaxLen = 64
+	// truncated HMAC-SHA256 keeps data within the limit
+	callbackSigLen   = 12
+	callbackNonceLen = 6
+	callbackFields   = 7
+)
+
+var (
+	errCallbackTampered = fmt.Errorf("buttons are tampered or issued by another bot instance")
+	errCallbackForeign  = fmt.Errorf("buttons were not issued in this message")
+	errCallbackExpired  = fmt.Errorf("buttons expired, send /start for fresh ones")
+	errCallbackUsed     = fmt.Errorf("buttons were already used")
+)
+
+// callbackData is the signed payload of a prompt button:
+// <kind> <action> <chain id> <proposal id> <nonce> <expiry> <signature>
+type callbackData struct {
+	kind    string
+	action  string
+	chainID string
+	propID  string
+	// identifies the prompt buttons belong to
+	nonce  string
+	expiry time.Time
+}
+
+func (d callbackData) payload() string {
+	return strings.Join([]string{
+		d.kind, d.action, d.chainID, d.propID, d.nonce, strconv.FormatInt(d.expiry.Unix(), 36),
+	}, " ")
+}
+
+func newCallbackNonce() (string, error) {
+	nonce := make([]byte, callbackNonceLen)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.Wrap(err, "failed to generate callback nonce")
+	}
+	return base64.RawURLEncoding.EncodeToString(nonce), nil
+}
+
+func newCallbackSecret() []byte {
+	secret := make([]byte, sha256.Size)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("failed to generate callback secret: %v", err)
+	}
+	return secret
+}
+
+func (app *App) callbackSignature(payload string) string {
+	mac := hmac.New(sha256.New, app.callbackSecret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:callbackSigLen])
+}
+
+func (app *App) signCallback(d callbackData) (string, error) {
+	payload := d.payload()
+	data := payload + " " + app.callbackSignature(payload)
+	if len(data) > callbackMaxLen {
+		return "", fmt.Errorf("callback data '%s' exceeds %d bytes", data, callbackMaxLen)
+	}
+	return data, nil
+}
+
+// verifyCallback checks that callback data is signed by the app, not expired,
+// comes from the prompt message it was issued for and was not used yet
+func (app *App) verifyCallback(press *ButtonPress) (callbackData, error) {
+	fields := strings.Fields(press.Data)
+	if len(fields) != callbackFields {
+		return callbackData{}, errCallbackTampered
+	}
+	payload := strings.Join(fields[:callbackFields-1], " ")
+	if !hmac.Equal([]byte(fields[callbackFields-1]), []byte(app.callbackSignature(payload))) {
+		return callbackData{}, errCallbackTampered
+	}
+	expiry, err := strconv.ParseInt(fields[5], 36, 64)
+	if err != nil {
+		return callbackData{}, errCallbackTampered
+	}
+	data := callbackData{
+		kind:    fields[0],
+		action:  fields[1],
+		chainID: fields[2],
+		propID:  fields[3],
+		nonce:   fields[4],
+		expiry:  time.Unix(expiry, 0),
+	}
+	if time.Now().After(data.expiry) {
+		return data, errCallbackExpired
+	}
+
+	prompts, err := app.store.ListPrompts(data.chainID, data.propID)
+	if err != nil {
+		return data, errors.Wrap(err, "failed to get prompts")
+	}
+	issued := false
+	for _, prompt := range prompts {
+		if prompt.Nonce == data.nonce &&
+			string(prompt.ChatID) == press.Message.ChatID &&
+			string(prompt.MessageID) == press.Message.MessageID {
+			issued = true
+			break
+		}
+	}
+	if !issued {
+		return data, errCallbackForeign
+	}
+
+	rec, err := app.store.GetProposal(data.chainID, data.propID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return data, errors.Wrap(err, "failed to get proposal")
+	}
+	for _, used := range rec.UsedCallbacks {
+		if used == data.nonce {
+			return data, errCallbackUsed
+		}
+	}
+	return data, nil
+}
+
+// useCallback makes the rest of the prompt buttons invalid
+func (app *App) useCallback(data callbackData) {
+	err := app.store.UpdateProposal(data.chainID, data.propID, func(rec *store.ProposalRecord) error {
+		rec.UsedCallbacks = append(rec.UsedCallbacks, data.nonce)
+		return nil
+	})
+	if err != nil {
+		log.Errorf("failed to store used callback of %s proposal %s: %v", data.chainID, data.propID, err)
+	}
+}
+
+// ProcessCallback verifies button data and routes it to vote or weighted vote handling
+func (app *App) ProcessCallback(ctx context.Context, press *ButtonPress) error {
+	log.Infof("received callback: %s", press.Data)
+	if err := app.authorize(press.From, RoleVoter); err != nil {
+		return app.notifyCallback(press, err.Error())
+	}
+	data, err := app.verifyCallback(press)
+	if err != nil {
+		log.Errorf("rejected callback '%s' from %s: %v", press.Data, press.From, err)
+		if errors.Is(err, errCallbackExpired) || errors.Is(err, errCallbackUsed) {
+			return app.reportCallbackErr(press, err)
+		}
+		// forwarded messages can not be edited by the bot
+		return app.notifyCallback(press, err.Error())
+	}
+	switch data.kind {
+	case callbackKindVote:
+		return app.ProcessVoteCallback(ctx, press, data)
+	case callbackKindWeighted:
+		return app.ProcessWeightedVoteCallback(ctx, press, data)
+	}
+	return app.reportCallbackErr(press, fmt.Errorf("unknown callback kind '%s'", data.kind))
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testChatID    = "-100123"
+	testMessageID = "42"
+)
+
+func callbackPress(data string, chatID string, messageID string) *ButtonPress {
+	return &ButtonPress{
+		From:    &User{ID: "1"},
+		Message: MessageRef{ChatID: chatID, MessageID: messageID},
+		Data:    data,
+	}
+}
+
+// issueCallback signs yes button data of a prompt stored as sent to the test chat
+func issueCallback(t *testing.T, app *App, expiry time.Time) (callbackData, string) {
+	nonce, err := newCallbackNonce()
+	require.NoError(t, err)
+	data := callbackData{
+		kind:    callbackKindVote,
+		action:  "y",
+		chainID: "kaiyo-1",
+		propID:  "291",
+		nonce:   nonce,
+		expiry:  expiry,
+	}
+	signed, err := app.signCallback(data)
+	require.NoError(t, err)
+	app.recordPrompt(data.chainID, data.propID, nonce, MessageRef{ChatID: testChatID, MessageID: testMessageID})
+	return data, signed
+}
+
+func TestVerifyCallback(t *testing.T) {
+	app := NewApp(nil, nil, nil, store.NewMemStore())
+	data, signed := issueCallback(t, app, time.Now().Add(time.Hour))
+	assert.LessOrEqual(t, len(signed), callbackMaxLen)
+
+	verified, err := app.verifyCallback(callbackPress(signed, testChatID, testMessageID))
+	require.NoError(t, err)
+	assert.Equal(t, data.kind, verified.kind)
+	assert.Equal(t, data.action, verified.action)
+	assert.Equal(t, data.chainID, verified.chainID)
+	assert.Equal(t, data.propID, verified.propID)
+	assert.Equal(t, data.nonce, verified.nonce)
+	assert.Equal(t, data.expiry.Unix(), verified.expiry.Unix())
+}
+
+func TestVerifyCallbackTampered(t *testing.T) {
+	app := NewApp(nil, nil, nil, store.NewMemStore())
+	data, _ := issueCallback(t, app, time.Now().Add(time.Hour))
+
+	// valid signature of yes must not vote no
+	data.action = "n"
+	tampered := data.payload() + " " + app.callbackSignature("v y kaiyo-1 291")
+	_, err := app.verifyCallback(callbackPress(tampered, testChatID, testMessageID))
+	assert.ErrorIs(t, err, errCallbackTampered)
+
+	// buttons of another bot instance
+	other := NewApp(nil, nil, nil, store.NewMemStore())
+	signed, err := other.signCallback(data)
+	require.NoError(t, err)
+	_, err = app.verifyCallback(callbackPress(signed, testChatID, testMessageID))
+	assert.ErrorIs(t, err, errCallbackTampered)
+
+	// data of older releases
+	_, err = app.verifyCallback(callbackPress("vote yes on kaiyo-1 291", testChatID, testMessageID))
+	assert.ErrorIs(t, err, errCallbackTampered)
+}
+
+func TestVerifyCallbackSharedSecret(t *testing.T) {
+	app := NewApp(nil, nil, nil, store.NewMemStore())
+	app.SetCallbackSecret("secret")
+	_, signed := issueCallback(t, app, time.Now().Add(time.Hour))
+
+	// restarted app with the same secret accepts buttons sent before
+	restarted := NewApp(nil, nil, nil, app.store)
+	restarted.SetCallbackSecret("secret")
+	_, err := restarted.verifyCallback(callbackPress(signed, testChatID, testMessageID))
+	assert.NoError(t, err)
+}
+
+func TestVerifyCallbackExpired(t *testing.T) {
+	app := NewApp(nil, nil, nil, store.NewMemStore())
+	_, signed := issueCallback(t, app, time.Now().Add(-time.Minute))
+
+	_, err := app.verifyCallback(callbackPress(signed, testChatID, testMessageID))
+	assert.ErrorIs(t, err, errCallbackExpired)
+}
+
+func TestVerifyCallbackForeignMessage(t *testing.T) {
+	app := NewApp(nil, nil, nil, store.NewMemStore())
+	_, signed := issueCallback(t, app, time.Now().Add(time.Hour))
+
+	// forwarded to another chat
+	_, err := app.verifyCallback(callbackPress(signed, "-100124", testMessageID))
+	assert.ErrorIs(t, err, errCallbackForeign)
+	// copied to another message of the same chat
+	_, err = app.verifyCallback(callbackPress(signed, testChatID, "43"))
+	assert.ErrorIs(t, err, errCallbackForeign)
+}
+
+func TestVerifyCallbackUsed(t *testing.T) {
+	app := NewApp(nil, nil, nil, store.NewMemStore())
+	data, signed := issueCallback(t, app, time.Now().Add(time.Hour))
+	app.useCallback(data)
+
+	_, err := app.verifyCallback(callbackPress(signed, testChatID, testMessageID))
+	assert.ErrorIs(t, err, errCallbackUsed)
+
+	// other buttons of the used prompt are rejected too
+	data.action = "n"
+	signed, err = app.signCallback(data)
+	require.NoError(t, err)
+	_, err = app.verifyCallback(callbackPress(signed, testChatID, testMessageID))
+	assert.ErrorIs(t, err, errCallbackUsed)
+}
+
+func TestSignCallbackTooLong(t *testing.T) {
+	app := NewApp(nil, nil, nil, store.NewMemStore())
+	_, err := app.signCallback(callbackData{
+		kind:    callbackKindVote,
+		action:  "y",
+		chainID: "a-very-long-chain-identifier-1",
+		propID:  "291",
+		nonce:   "AAAAAAAA",
+		expiry:  time.Now(),
+	})
+	assert.Error(t, err)
+}
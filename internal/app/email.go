@@ -0,0 +1,182 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	htmltemplate "html/template"
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// new proposals waiting to be mailed
+	proposalMailQueueSize = 100
+)
+
+var (
+	//go:embed digest.html.tmpl
+	digestTmplText string
+	digestTmpl     = htmltemplate.Must(htmltemplate.New("digest").Parse(digestTmplText))
+
+	//go:embed proposalMail.html.tmpl
+	proposalMailTmplText string
+	proposalMailTmpl     = htmltemplate.Must(htmltemplate.New("proposalMail").Parse(proposalMailTmplText))
+)
+
+// Mailer sends html mails to the team
+type Mailer interface {
+	Send(subject string, html string) error
+}
+
+// digest is digest.html.tmpl data
+type digest struct {
+	Generated time.Time
+	Chains    []digestChain
+}
+
+type digestChain struct {
+	Name      string
+	Proposals []vote.Proposal
+	// set if proposals of the chain are unknown
+	Error string
+}
+
+// Digest mails unvoted proposals of all chains once a day
+type Digest struct {
+	app    *App
+	mailer Mailer
+	// UTC time of day
+	at time.Duration
+}
+
+func NewDigest(app *App, mailer Mailer, at time.Duration) *Digest {
+	return &Digest{
+		app:    app,
+		mailer: mailer,
+		at:     at,
+	}
+}
+
+func (d *Digest) Run(ctx context.Context) error {
+	for {
+		timer := time.NewTimer(time.Until(nextDigest(time.Now(), d.at)))
+		select {
+		case <-timer.C:
+			if err := d.send(ctx); err != nil {
+				log.Errorf("failed to send digest: %v", err)
+			}
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		}
+	}
+}
+
+// nextDigest returns the first time of day at after now
+func nextDigest(now time.Time, at time.Duration) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Add(at)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// send mails the digest unless every proposal is voted
+func (d *Digest) send(ctx context.Context) error {
+	data := digest{Generated: time.Now().UTC()}
+	pending := 0
+	for _, chain := range d.app.chains {
+		digestChain := d.checkChain(ctx, chain)
+		if len(digestChain.Proposals) == 0 && digestChain.Error == "" {
+			continue
+		}
+		pending += len(digestChain.Proposals)
+		data.Chains = append(data.Chains, digestChain)
+	}
+	if len(data.Chains) == 0 {
+		log.Info("no unvoted proposals, digest is not sent")
+		return nil
+	}
+	html := bytes.Buffer{}
+	if err := digestTmpl.Execute(&html, data); err != nil {
+		return errors.Wrap(err, "failed to render digest")
+	}
+	subject := fmt.Sprintf("%d proposals waiting for your vote", pending)
+	if err := d.mailer.Send(subject, html.String()); err != nil {
+		return errors.Wrap(err, "failed to mail digest")
+	}
+	log.Infof("mailed digest of %d unvoted proposals", pending)
+	return nil
+}
+
+func (d *Digest) checkChain(ctx context.Context, chain Chain) digestChain {
+	ctx, cancel := context.WithTimeout(ctx, cmdTimeout)
+	defer cancel()
+	result := digestChain{Name: chain.Name}
+	proposals, err := chain.Voter.GetVoting(ctx)
+	if err != nil {
+		log.Errorf("digest failed to get %s proposals: %v", chain.ID, err)
+		result.Error = err.Error()
+		return result
+	}
+	// voted proposals are left out by GetVoting
+	result.Proposals = proposals
+	return result
+}
+
+// ProposalMailer mails proposals as soon as they are detected
+type ProposalMailer struct {
+	mailer Mailer
+	queue  chan Notification
+}
+
+func NewProposalMailer(mailer Mailer) *ProposalMailer {
+	return &ProposalMailer{
+		mailer: mailer,
+		queue:  make(chan Notification, proposalMailQueueSize),
+	}
+}
+
+// Notify queues proposal_detected notifications for Run, others are ignored
+func (m *ProposalMailer) Notify(n Notification) {
+	if n.Type != ProposalDetected {
+		return
+	}
+	select {
+	case m.queue <- n:
+	default:
+		log.Errorf("proposal mail queue is full, %s proposal %s is not mailed", n.ChainID, n.ProposalID)
+	}
+}
+
+func (m *ProposalMailer) Run(ctx context.Context) error {
+	for {
+		select {
+		case n := <-m.queue:
+			if err := m.send(n); err != nil {
+				log.Errorf("failed to mail %s proposal %s: %v", n.ChainID, n.ProposalID, err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (m *ProposalMailer) send(n Notification) error {
+	html := bytes.Buffer{}
+	if err := proposalMailTmpl.Execute(&html, n); err != nil {
+		return errors.Wrap(err, "failed to render proposal mail")
+	}
+	subject := fmt.Sprintf("New %s proposal %s: %s", n.Chain, n.ProposalID, n.Title)
+	if err := m.mailer.Send(subject, html.String()); err != nil {
+		return err
+	}
+	log.Infof("mailed %s proposal %s", n.ChainID, n.ProposalID)
+	return nil
+}
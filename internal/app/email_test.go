@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sentMail struct {
+	subject string
+	html    string
+}
+
+type fakeMailer struct {
+	mails []sentMail
+}
+
+func (m *fakeMailer) Send(subject string, html string) error {
+	m.mails = append(m.mails, sentMail{subject: subject, html: html})
+	return nil
+}
+
+func TestNextDigest(t *testing.T) {
+	at := time.Hour * 9
+	now := time.Date(2023, 11, 14, 8, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2023, 11, 14, 9, 0, 0, 0, time.UTC), nextDigest(now, at))
+	now = time.Date(2023, 11, 14, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2023, 11, 15, 9, 0, 0, 0, time.UTC), nextDigest(now, at))
+}
+
+func TestDigest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	kujira := vote.NewMockVoter(ctrl)
+	osmosis := vote.NewMockVoter(ctrl)
+	chains := []Chain{
+		{ID: "kaiyo-1", Name: "Kujira", Voter: kujira},
+		{ID: "osmosis-1", Name: "Osmosis", Voter: osmosis},
+	}
+	app := NewApp(chains, &fakeMessenger{}, nil, store.NewMemStore())
+	mailer := &fakeMailer{}
+	digest := NewDigest(app, mailer, time.Hour*9)
+
+	end := time.Now().Add(time.Hour * 30)
+	kujira.EXPECT().GetVoting(gomock.Any()).Return([]vote.Proposal{
+		{Id: "291", Title: "GHOST <v2>", VotingEndTime: end, DeadlineHrs: 30},
+	}, nil)
+	osmosis.EXPECT().GetVoting(gomock.Any()).Return(nil, errors.New("connection refused"))
+	require.NoError(t, digest.send(context.Background()))
+
+	require.Len(t, mailer.mails, 1)
+	assert.Equal(t, "1 proposals waiting for your vote", mailer.mails[0].subject)
+	html := mailer.mails[0].html
+	assert.Contains(t, html, "<h3>Kujira</h3>")
+	assert.Contains(t, html, "GHOST &lt;v2&gt;")
+	assert.Contains(t, html, "in 30.0 hours")
+	assert.Contains(t, html, "Failed to get proposals:</b> connection refused")
+
+	// nothing to vote on
+	kujira.EXPECT().GetVoting(gomock.Any()).Return(nil, nil)
+	osmosis.EXPECT().GetVoting(gomock.Any()).Return(nil, nil)
+	require.NoError(t, digest.send(context.Background()))
+	assert.Len(t, mailer.mails, 1)
+}
+
+func TestProposalMailer(t *testing.T) {
+	mailer := &fakeMailer{}
+	proposalMailer := NewProposalMailer(mailer)
+	proposalMailer.Notify(Notification{Type: VoteConfirmed, ProposalID: "290"})
+	end := time.Date(2023, 11, 15, 9, 0, 0, 0, time.UTC)
+	proposalMailer.Notify(Notification{
+		Type:          ProposalDetected,
+		ChainID:       "kaiyo-1",
+		Chain:         "Kujira",
+		ProposalID:    "291",
+		Title:         "GHOST",
+		Description:   "<script>alert(1)</script>",
+		VotingEndTime: &end,
+	})
+	require.Len(t, proposalMailer.queue, 1)
+	require.NoError(t, proposalMailer.send(<-proposalMailer.queue))
+
+	require.Len(t, mailer.mails, 1)
+	assert.Equal(t, "New Kujira proposal 291: GHOST", mailer.mails[0].subject)
+	assert.Contains(t, mailer.mails[0].html, "Voting ends 2023-11-15 09:00 UTC")
+	assert.Contains(t, mailer.mails[0].html, "&lt;script&gt;")
+}
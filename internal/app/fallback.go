@@ -0,0 +1,154 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	fallbackApprover = "fallback vote"
+)
+
+// FallbackVoter casts the configured fallback option on proposals nobody has
+// voted on when voting end gets closer than FallbackBefore of the chain
+type FallbackVoter struct {
+	app      *App
+	chatID   string
+	interval time.Duration
+}
+
+func NewFallbackVoter(app *App, chatID string, interval time.Duration) *FallbackVoter {
+	return &FallbackVoter{
+		app:      app,
+		chatID:   chatID,
+		interval: interval,
+	}
+}
+
+func (f *FallbackVoter) Run(ctx context.Context) error {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		f.check(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (f *FallbackVoter) check(ctx context.Context) {
+	for _, chain := range f.app.chains {
+		if chain.FallbackVote == vote.VoteOptionUnspecified || chain.FallbackBefore <= 0 {
+			continue
+		}
+		f.checkChain(ctx, chain)
+	}
+}
+
+func (f *FallbackVoter) checkChain(ctx context.Context, chain Chain) {
+	queryCtx, cancel := context.WithTimeout(ctx, cmdTimeout)
+	defer cancel()
+	proposals, err := chain.Voter.GetVoting(queryCtx)
+	if err != nil {
+		log.Errorf("fallback voter failed to get %s proposals: %v", chain.ID, err)
+		return
+	}
+	for _, prop := range proposals {
+		left := time.Until(prop.VotingEndTime)
+		if left <= 0 || left > chain.FallbackBefore {
+			continue
+		}
+		rec, err := f.app.store.GetProposal(chain.ID, prop.Id)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			log.Errorf("fallback voter failed to get stored %s proposal %s, err: %v", chain.ID, prop.Id, err)
+			continue
+		}
+		// vote tx may be not included yet on the next check
+		if rec.FallbackVoted || f.app.isSubmitting(pendingKey(chain.ID, prop.Id)) {
+			continue
+		}
+		if voted, ok := f.hasVoted(ctx, chain, prop.Id); voted || !ok {
+			continue
+		}
+		f.castFallbackVote(ctx, chain, prop)
+	}
+}
+
+// castFallbackVote holds the proposal against votes from chat and checks the
+// vote once more, a chat vote may have been confirmed since the first check
+func (f *FallbackVoter) castFallbackVote(ctx context.Context, chain Chain, prop vote.Proposal) {
+	key := pendingKey(chain.ID, prop.Id)
+	if !f.app.startSubmitting(key) {
+		return
+	}
+	defer f.app.doneSubmitting(key)
+	if voted, ok := f.hasVoted(ctx, chain, prop.Id); voted || !ok {
+		return
+	}
+	if err := f.app.CastFallbackVote(ctx, chain, prop, f.chatID); err != nil {
+		log.Errorf("failed to cast fallback vote on %s proposal %s, err: %v", chain.ID, prop.Id, err)
+	}
+}
+
+// hasVoted is false for ok if the vote could not be checked, each check gets
+// its own timeout as fallback votes cast in between take up to VoteTimeout
+func (f *FallbackVoter) hasVoted(ctx context.Context, chain Chain, propID string) (voted bool, ok bool) {
+	ctx, cancel := context.WithTimeout(ctx, cmdTimeout)
+	defer cancel()
+	voted, err := chain.Voter.HasVoted(ctx, propID)
+	if err != nil {
+		log.Errorf("fallback voter failed to check vote on %s proposal %s, err: %v", chain.ID, propID, err)
+		return false, false
+	}
+	return voted, true
+}
+
+// CastFallbackVote votes the chain fallback option on proposal and reports it to chat
+func (app *App) CastFallbackVote(ctx context.Context, chain Chain, prop vote.Proposal, chatID string) error {
+	by := []string{fallbackApprover}
+	app.notifyVoteSubmitted(chain, prop.Id, chain.FallbackVote.String(), by)
+	ctx, cancel := context.WithTimeout(ctx, VoteTimeout)
+	defer cancel()
+	result, err := chain.Voter.Vote(ctx, prop.Id, chain.FallbackVote)
+	app.notifyVoteResult(chain, prop.Id, chain.FallbackVote.String(), by, result, err)
+	if err != nil {
+		text := fmt.Sprintf("Fallback vote %s on %s proposal %s failed: %v", chain.FallbackVote, chain.Name, prop.Id, err)
+		if err := app.sendText(chatID, text); err != nil {
+			log.Errorf("failed to report fallback vote failure: %v", err)
+		}
+		return errors.Wrap(err, "fallback vote failed")
+	}
+	log.Infof("cast fallback vote %s on %s proposal %s", chain.FallbackVote, chain.ID, prop.Id)
+	app.recordProposal(chain, prop)
+	err = app.store.UpdateProposal(chain.ID, prop.Id, func(rec *store.ProposalRecord) error {
+		rec.FallbackVoted = true
+		return nil
+	})
+	if err != nil {
+		log.Errorf("failed to store fallback vote on %s proposal %s: %v", chain.ID, prop.Id, err)
+	}
+	err = app.store.AddVote(store.VoteRecord{
+		ChainID:    chain.ID,
+		ProposalID: prop.Id,
+		Option:     chain.FallbackVote.String(),
+		TxHash:     result.TxHash,
+		ApprovedBy: by,
+		ChatID:     store.MessengerID(chatID),
+		Submitted:  time.Now().UTC(),
+	})
+	if err != nil {
+		log.Errorf("failed to store fallback vote on %s proposal %s: %v", chain.ID, prop.Id, err)
+	}
+	text := fmt.Sprintf(
+		"Nobody voted on %s proposal %s '%s', fallback vote %s was cast %.2f hours before voting end\n%s",
+		chain.Name, prop.Id, prop.Title, chain.FallbackVote, time.Until(prop.VotingEndTime).Hours(), result)
+	return app.sendText(chatID, text)
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallbackVoterTiming(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		left          time.Duration
+		fallbackVoted bool
+		// a vote from chat is being cast
+		submitting bool
+		// results of the vote checks in order
+		voted []bool
+		cast  bool
+	}{
+		{"before window", time.Hour * 3, false, false, nil, false},
+		{"in window", time.Minute * 30, false, false, []bool{false, false}, true},
+		{"in window voted", time.Minute * 30, false, false, []bool{true}, false},
+		{"voted since first check", time.Minute * 30, false, false, []bool{false, true}, false},
+		{"submitting from chat", time.Minute * 30, false, true, nil, false},
+		{"already cast", time.Minute * 30, true, false, nil, false},
+		{"ended", -time.Minute, false, false, nil, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			voter := vote.NewMockVoter(ctrl)
+			messenger := &fakeMessenger{}
+			chain := Chain{
+				ID:             "kaiyo-1",
+				Name:           "Kujira",
+				Voter:          voter,
+				FallbackVote:   vote.VoteOptionAbstain,
+				FallbackBefore: time.Hour,
+			}
+			app := NewApp([]Chain{chain}, messenger, nil, store.NewMemStore())
+			require.NoError(t, app.store.UpdateProposal("kaiyo-1", "291", func(rec *store.ProposalRecord) error {
+				rec.FallbackVoted = tc.fallbackVoted
+				return nil
+			}))
+			prop := vote.Proposal{Id: "291", Title: "GHOST", VotingEndTime: time.Now().Add(tc.left)}
+			voter.EXPECT().GetVoting(gomock.Any()).Return([]vote.Proposal{prop}, nil)
+			if tc.submitting {
+				require.True(t, app.startSubmitting(pendingKey("kaiyo-1", "291")))
+			}
+			var checks []*gomock.Call
+			for _, voted := range tc.voted {
+				checks = append(checks, voter.EXPECT().HasVoted(gomock.Any(), "291").Return(voted, nil))
+			}
+			gomock.InOrder(checks...)
+			if tc.cast {
+				voter.EXPECT().Vote(gomock.Any(), "291", vote.VoteOptionAbstain).Return(&vote.VoteResult{TxHash: "ABCD"}, nil)
+			}
+
+			NewFallbackVoter(app, "-100123", time.Minute).check(context.Background())
+			rec, err := app.store.GetProposal("kaiyo-1", "291")
+			require.NoError(t, err)
+			assert.Equal(t, tc.cast || tc.fallbackVoted, rec.FallbackVoted)
+			if tc.cast {
+				require.Len(t, messenger.sent, 1)
+				assert.Contains(t, messenger.sent[0].text, "fallback vote abstain was cast")
+			} else {
+				assert.Empty(t, messenger.sent)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package app
+
+import (
+	"context"
+
+	"github.com/kostage/cosmos_voter/internal/vote"
+	log "github.com/sirupsen/logrus"
+)
+
+// expectedFee returns simulated fee of a vote on the proposal, empty if the
+// voter can't tell it, the vote is still offered then
+func (app *App) expectedFee(ctx context.Context, chain Chain, propID string) string {
+	estimator, ok := chain.Voter.(vote.FeeEstimator)
+	if !ok {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(ctx, cmdTimeout)
+	defer cancel()
+	estimate, err := estimator.EstimateFee(ctx, propID)
+	if err != nil {
+		log.Warnf("failed to estimate fee of vote on %s proposal %s: %v", chain.ID, propID, err)
+		return ""
+	}
+	return estimate.String()
+}
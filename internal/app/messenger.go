@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+)
+
+// Messenger is a chat backend users talk to the app through, chat, message
+// and user ids are opaque strings of the backend
+type Messenger interface {
+	// Receive passes commands and button presses to handler until ctx is done
+	// or handler fails
+	Receive(ctx context.Context, handler func(Event) error) error
+	// Send posts text to chat with a row of buttons, buttons may be empty
+	Send(chatID string, text string, buttons []Button) (MessageRef, error)
+	// Edit replaces text and buttons of a sent message, empty buttons are removed
+	Edit(msg MessageRef, text string, buttons []Button) error
+	// Answer acknowledges a button press, text is shown to the presser where
+	// supported, empty text only stops the press 'loading' state
+	Answer(press *ButtonPress, text string) error
+}
+
+// Event is either a command or a button press
+type Event struct {
+	Command *Command
+	Press   *ButtonPress
+}
+
+// User sent a command or pressed a button
+type User struct {
+	ID string
+	// messenger user name, shown if the principal has no configured name
+	Name string
+}
+
+func (u *User) String() string {
+	return u.Name
+}
+
+// MessageRef identifies a sent message to edit it later
+type MessageRef struct {
+	ChatID    string
+	MessageID string
+}
+
+// Button is pressed with its signed callback data
+type Button struct {
+	Text string
+	Data string
+}
+
+// Command is a bot command like /start sent to a chat
+type Command struct {
+	ChatID string
+	// nil if the messenger did not tell
+	From *User
+	// without command prefix
+	Name string
+	Args string
+}
+
+// ButtonPress is a press of a button sent by the app
+type ButtonPress struct {
+	// answers the press, meaning depends on the messenger
+	ID string
+	// nil if the messenger did not tell
+	From    *User
+	Message MessageRef
+	Data    string
+}
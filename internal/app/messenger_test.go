@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/kostage/cosmos_voter/internal/policy"
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sentMessage struct {
+	ref     MessageRef
+	text    string
+	buttons []Button
+}
+
+// fakeMessenger records what the app sends, edits and answers
+type fakeMessenger struct {
+	sent     []sentMessage
+	edited   []sentMessage
+	answered []string
+	// returned by Send if set
+	sendErr error
+}
+
+func (m *fakeMessenger) Receive(ctx context.Context, handler func(Event) error) error {
+	return nil
+}
+
+func (m *fakeMessenger) Send(chatID string, text string, buttons []Button) (MessageRef, error) {
+	if m.sendErr != nil {
+		return MessageRef{}, m.sendErr
+	}
+	ref := MessageRef{ChatID: chatID, MessageID: fmt.Sprint(len(m.sent) + 1)}
+	m.sent = append(m.sent, sentMessage{ref: ref, text: text, buttons: buttons})
+	return ref, nil
+}
+
+func (m *fakeMessenger) Edit(ref MessageRef, text string, buttons []Button) error {
+	m.edited = append(m.edited, sentMessage{ref: ref, text: text, buttons: buttons})
+	return nil
+}
+
+func (m *fakeMessenger) Answer(press *ButtonPress, text string) error {
+	m.answered = append(m.answered, text)
+	return nil
+}
+
+func TestVotePromptPress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	voter := vote.NewMockVoter(ctrl)
+	messenger := &fakeMessenger{}
+	chains := []Chain{{ID: "kaiyo-1", Name: "Kujira", Voter: voter}}
+	principals := []Principal{{ID: "U0G9QF9C6", Name: "kostage", Role: RoleVoter}}
+	app := NewApp(chains, messenger, principals, store.NewMemStore())
+
+	prop := vote.Proposal{Id: "291", Title: "GHOST", VotingEndTime: time.Now().Add(time.Hour)}
+	require.NoError(t, app.SendVotePrompt(context.Background(), chains[0], prop, "C024BE91L"))
+	require.Len(t, messenger.sent, 2)
+	keyboard := messenger.sent[1]
+	require.Len(t, keyboard.buttons, len(voteButtons))
+	assert.Equal(t, "Yes", keyboard.buttons[0].Text)
+
+	voter.EXPECT().Vote(gomock.Any(), "291", vote.VoteOptionYes).Return(&vote.VoteResult{TxHash: "ABCD"}, nil)
+	press := &ButtonPress{
+		From:    &User{ID: "U0G9QF9C6", Name: "kostage.slack"},
+		Message: keyboard.ref,
+		Data:    keyboard.buttons[0].Data,
+	}
+	require.NoError(t, app.ProcessCallback(context.Background(), press))
+	app.submissions.Wait()
+	require.Len(t, messenger.edited, 2)
+	assert.Equal(t, "Submitting yes on Kujira proposal 291...", messenger.edited[0].text)
+	assert.Equal(t, []string{"Submitting"}, messenger.answered)
+	assert.Contains(t, messenger.edited[1].text, "You voted yes on Kujira proposal 291")
+	assert.Empty(t, messenger.edited[1].buttons)
+	votes, err := app.store.ListVotes("kaiyo-1", "291")
+	require.NoError(t, err)
+	require.Len(t, votes, 1)
+	assert.Equal(t, []string{"kostage"}, votes[0].ApprovedBy)
+	assert.Equal(t, store.MessengerID("C024BE91L"), votes[0].ChatID)
+
+	// unknown users are told so without voting
+	press.From = &User{ID: "U0STRANGER", Name: "stranger"}
+	require.NoError(t, app.ProcessCallback(context.Background(), press))
+	assert.Equal(t, "unknown user", messenger.answered[len(messenger.answered)-1])
+}
+
+func TestStartCommandOnlyPrompts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	voter := vote.NewMockVoter(ctrl)
+	messenger := &fakeMessenger{}
+	chains := []Chain{{ID: "kaiyo-1", Name: "Kujira", Voter: voter}}
+	principals := []Principal{{ID: "U0G9QF9C6", Name: "kostage", Role: RoleViewer}}
+	app := NewApp(chains, messenger, principals, store.NewMemStore())
+	p, err := policy.ParsePolicy([]byte("default: yes"))
+	require.NoError(t, err)
+	app.SetPolicy(p, false)
+
+	prop := vote.Proposal{Id: "291", Title: "GHOST", VotingEndTime: time.Now().Add(time.Hour)}
+	voter.EXPECT().GetVoting(gomock.Any()).Return([]vote.Proposal{prop}, nil)
+	// the mock fails the test on Vote
+	cmd := &Command{ChatID: "C024BE91L", From: &User{ID: "U0G9QF9C6", Name: "kostage"}, Name: "start"}
+	require.NoError(t, app.ProcessCommand(context.Background(), cmd))
+	require.Len(t, messenger.sent, 2)
+	assert.Len(t, messenger.sent[1].buttons, len(voteButtons))
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/vote"
+)
+
+type NotificationType string
+
+const (
+	ProposalDetected NotificationType = "proposal_detected"
+	ReminderSent     NotificationType = "reminder"
+	VoteSubmitted    NotificationType = "vote_submitted"
+	VoteFailed       NotificationType = "vote_failed"
+	VoteConfirmed    NotificationType = "vote_confirmed"
+)
+
+// Notification is a governance event reported besides the chat
+type Notification struct {
+	Type       NotificationType `json:"type"`
+	Time       time.Time        `json:"time"`
+	ChainID    string           `json:"chain_id"`
+	Chain      string           `json:"chain"`
+	ProposalID string           `json:"proposal_id"`
+	// set for proposal_detected and reminder, description for proposal_detected only
+	Title         string     `json:"title,omitempty"`
+	Description   string     `json:"description,omitempty"`
+	VotingEndTime *time.Time `json:"voting_end_time,omitempty"`
+	// last reminder before voting end
+	Urgent bool `json:"urgent,omitempty"`
+	// set for vote events, approvers or policy rule who decided the vote
+	Option string   `json:"option,omitempty"`
+	By     []string `json:"by,omitempty"`
+	// set for vote_confirmed
+	TxHash  string `json:"tx_hash,omitempty"`
+	Height  int64  `json:"height,omitempty"`
+	GasUsed int64  `json:"gas_used,omitempty"`
+	Fee     string `json:"fee,omitempty"`
+	// set for vote_failed
+	Error string `json:"error,omitempty"`
+}
+
+// Notifier delivers notifications elsewhere than the messenger, Notify must
+// not block the app, slow deliveries are queued
+type Notifier interface {
+	Notify(n Notification)
+}
+
+// AddNotifier makes the app report governance events to notifier
+func (app *App) AddNotifier(notifier Notifier) {
+	app.notifiers = append(app.notifiers, notifier)
+}
+
+func (app *App) notify(n Notification) {
+	n.Time = time.Now().UTC()
+	for _, notifier := range app.notifiers {
+		notifier.Notify(n)
+	}
+}
+
+func (app *App) notifyProposal(typ NotificationType, chain Chain, prop vote.Proposal, urgent bool) {
+	n := Notification{
+		Type:       typ,
+		ChainID:    chain.ID,
+		Chain:      chain.Name,
+		ProposalID: prop.Id,
+		Title:      prop.Title,
+		Urgent:     urgent,
+	}
+	if typ == ProposalDetected {
+		n.Description = prop.Description
+	}
+	if !prop.VotingEndTime.IsZero() {
+		end := prop.VotingEndTime.UTC()
+		n.VotingEndTime = &end
+	}
+	app.notify(n)
+}
+
+func (app *App) notifyVoteSubmitted(chain Chain, propID string, option string, by []string) {
+	app.notify(Notification{
+		Type:       VoteSubmitted,
+		ChainID:    chain.ID,
+		Chain:      chain.Name,
+		ProposalID: propID,
+		Option:     option,
+		By:         by,
+	})
+}
+
+// notifyVoteResult reports vote_failed if err is set, vote_confirmed otherwise
+func (app *App) notifyVoteResult(chain Chain, propID string, option string, by []string, result *vote.VoteResult, err error) {
+	n := Notification{
+		Type:       VoteConfirmed,
+		ChainID:    chain.ID,
+		Chain:      chain.Name,
+		ProposalID: propID,
+		Option:     option,
+		By:         by,
+	}
+	if err != nil {
+		n.Type = VoteFailed
+		n.Error = err.Error()
+	} else if result != nil {
+		n.TxHash = result.TxHash
+		n.Height = result.Height
+		n.GasUsed = result.GasUsed
+		n.Fee = result.Fee
+	}
+	app.notify(n)
+}
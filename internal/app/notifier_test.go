@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingNotifier struct {
+	notifications []Notification
+}
+
+func (n *recordingNotifier) Notify(notification Notification) {
+	n.notifications = append(n.notifications, notification)
+}
+
+func TestFallbackVoteNotifications(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	voter := vote.NewMockVoter(ctrl)
+	chain := Chain{ID: "kaiyo-1", Name: "Kujira", Voter: voter, FallbackVote: vote.VoteOptionAbstain}
+	app := NewApp([]Chain{chain}, &fakeMessenger{}, nil, store.NewMemStore())
+	notifier := &recordingNotifier{}
+	app.AddNotifier(notifier)
+	prop := vote.Proposal{Id: "291", Title: "GHOST", VotingEndTime: time.Now().Add(time.Minute * 10)}
+
+	voter.EXPECT().Vote(gomock.Any(), "291", vote.VoteOptionAbstain).Return(nil, errors.New("out of gas"))
+	assert.Error(t, app.CastFallbackVote(context.Background(), chain, prop, "C024BE91L"))
+	voter.EXPECT().Vote(gomock.Any(), "291", vote.VoteOptionAbstain).Return(&vote.VoteResult{TxHash: "ABCD", Height: 100}, nil)
+	require.NoError(t, app.CastFallbackVote(context.Background(), chain, prop, "C024BE91L"))
+
+	require.Len(t, notifier.notifications, 4)
+	types := []NotificationType{}
+	for _, n := range notifier.notifications {
+		types = append(types, n.Type)
+		assert.Equal(t, "kaiyo-1", n.ChainID)
+		assert.Equal(t, "291", n.ProposalID)
+		assert.Equal(t, "abstain", n.Option)
+		assert.Equal(t, []string{fallbackApprover}, n.By)
+	}
+	assert.Equal(t, []NotificationType{VoteSubmitted, VoteFailed, VoteSubmitted, VoteConfirmed}, types)
+	assert.Equal(t, "out of gas", notifier.notifications[1].Error)
+	assert.Equal(t, "ABCD", notifier.notifications[3].TxHash)
+	assert.Equal(t, int64(100), notifier.notifications[3].Height)
+}
+
+func TestPollerNotifiesDetectedOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	voter := vote.NewMockVoter(ctrl)
+	chain := Chain{ID: "kaiyo-1", Name: "Kujira", Voter: voter}
+	principals := []Principal{{ID: "U0G9QF9C6", Name: "kostage", Role: RoleViewer}}
+	app := NewApp([]Chain{chain}, &fakeMessenger{}, principals, store.NewMemStore())
+	notifier := &recordingNotifier{}
+	app.AddNotifier(notifier)
+	prop := vote.Proposal{Id: "291", Title: "GHOST", VotingEndTime: time.Now().Add(time.Hour)}
+	voter.EXPECT().GetVoting(gomock.Any()).Return([]vote.Proposal{prop}, nil).Times(3)
+
+	poller := NewPoller(app, "C024BE91L", time.Minute)
+	poller.poll(context.Background())
+	poller.poll(context.Background())
+	// /start prompts again without detecting the proposal again
+	cmd := &Command{ChatID: "C024BE91L", From: &User{ID: "U0G9QF9C6"}, Name: "start"}
+	require.NoError(t, app.ProcessCommand(context.Background(), cmd))
+
+	require.Len(t, notifier.notifications, 1)
+	assert.Equal(t, ProposalDetected, notifier.notifications[0].Type)
+	assert.Equal(t, "291", notifier.notifications[0].ProposalID)
+}
+
+func TestPollerNotifiesDetectedOnceWhenPromptFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	voter := vote.NewMockVoter(ctrl)
+	chain := Chain{ID: "kaiyo-1", Name: "Kujira", Voter: voter}
+	messenger := &fakeMessenger{sendErr: errors.New("chat not found")}
+	app := NewApp([]Chain{chain}, messenger, nil, store.NewMemStore())
+	notifier := &recordingNotifier{}
+	app.AddNotifier(notifier)
+	prop := vote.Proposal{Id: "291", Title: "GHOST", VotingEndTime: time.Now().Add(time.Hour)}
+	voter.EXPECT().GetVoting(gomock.Any()).Return([]vote.Proposal{prop}, nil).Times(3)
+
+	poller := NewPoller(app, "C024BE91L", time.Minute)
+	poller.poll(context.Background())
+	poller.poll(context.Background())
+	// the prompt is sent once the chat is back
+	messenger.sendErr = nil
+	poller.poll(context.Background())
+
+	assert.Len(t, messenger.sent, 2)
+	require.Len(t, notifier.notifications, 1)
+	assert.Equal(t, ProposalDetected, notifier.notifications[0].Type)
+}
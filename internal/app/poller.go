@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Poller periodically looks for proposals in voting period and sends
+// vote prompts for those not announced yet
+type Poller struct {
+	app      *App
+	chatID   string
+	interval time.Duration
+}
+
+func NewPoller(app *App, chatID string, interval time.Duration) *Poller {
+	return &Poller{
+		app:      app,
+		chatID:   chatID,
+		interval: interval,
+	}
+}
+
+func (p *Poller) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.poll(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (p *Poller) poll(ctx context.Context) {
+	for _, chain := range p.app.chains {
+		p.pollChain(ctx, chain)
+	}
+}
+
+func (p *Poller) pollChain(ctx context.Context, chain Chain) {
+	queryCtx, cancel := context.WithTimeout(ctx, cmdTimeout)
+	defer cancel()
+	proposals, err := chain.Voter.GetVoting(queryCtx)
+	if err != nil {
+		log.Errorf("poller failed to get %s proposals: %v", chain.ID, err)
+		return
+	}
+	for _, prop := range proposals {
+		rec, err := p.app.store.GetProposal(chain.ID, prop.Id)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			log.Errorf("poller failed to get stored %s proposal %s, err: %v", chain.ID, prop.Id, err)
+			continue
+		}
+		if rec.Announced {
+			continue
+		}
+		if !rec.Detected {
+			p.detect(chain, prop)
+		}
+		if err := p.app.ProposeVote(ctx, chain, prop, p.chatID); err != nil {
+			log.Errorf("poller failed to send prompt for %s proposal %s, err: %v", chain.ID, prop.Id, err)
+			continue
+		}
+		err = p.app.store.UpdateProposal(chain.ID, prop.Id, func(rec *store.ProposalRecord) error {
+			rec.Announced = true
+			return nil
+		})
+		if err != nil {
+			log.Errorf("poller failed to store announced %s proposal %s, err: %v", chain.ID, prop.Id, err)
+		}
+		log.Infof("poller announced %s proposal: %s", chain.ID, prop.Id)
+	}
+}
+
+// detect notifies the proposal is detected, before the prompt so that auto-vote
+// notifications follow detection
+func (p *Poller) detect(chain Chain, prop vote.Proposal) {
+	err := p.app.store.UpdateProposal(chain.ID, prop.Id, func(rec *store.ProposalRecord) error {
+		rec.Detected = true
+		return nil
+	})
+	if err != nil {
+		log.Errorf("poller failed to store detected %s proposal %s, err: %v", chain.ID, prop.Id, err)
+	}
+	p.app.notifyProposal(ProposalDetected, chain, prop, false)
+}
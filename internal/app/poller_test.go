@@ -0,0 +1,53 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPollerAnnouncesOnce(t *testing.T) {
+	prop := vote.Proposal{Id: "291", Title: "GHOST", VotingEndTime: time.Now().Add(time.Hour)}
+	for _, tc := range []struct {
+		name      string
+		announced bool
+		votingErr error
+		sendErr   error
+		// prompt messages sent by the poll
+		sent int
+		// announced state stored after the poll
+		stored bool
+	}{
+		{"new proposal", false, nil, nil, 2, true},
+		{"already announced", true, nil, nil, 0, true},
+		{"query failed", false, errors.New("node is down"), nil, 0, false},
+		// announced on the next poll
+		{"prompt failed", false, nil, errors.New("chat not found"), 0, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			voter := vote.NewMockVoter(ctrl)
+			messenger := &fakeMessenger{sendErr: tc.sendErr}
+			chain := Chain{ID: "kaiyo-1", Name: "Kujira", Voter: voter}
+			app := NewApp([]Chain{chain}, messenger, nil, store.NewMemStore())
+			require.NoError(t, app.store.UpdateProposal("kaiyo-1", "291", func(rec *store.ProposalRecord) error {
+				rec.Announced = tc.announced
+				return nil
+			}))
+			voter.EXPECT().GetVoting(gomock.Any()).Return([]vote.Proposal{prop}, tc.votingErr)
+
+			NewPoller(app, "-100123", time.Minute).poll(context.Background())
+			assert.Len(t, messenger.sent, tc.sent)
+			rec, err := app.store.GetProposal("kaiyo-1", "291")
+			require.NoError(t, err)
+			assert.Equal(t, tc.stored, rec.Announced)
+		})
+	}
+}
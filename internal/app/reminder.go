@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Reminder re-sends vote prompts for unvoted proposals each time voting end
+// gets closer than one of thresholds, the last threshold reminder is urgent
+type Reminder struct {
+	app        *App
+	chatID     string
+	interval   time.Duration
+	thresholds []time.Duration
+}
+
+func NewReminder(app *App, chatID string, interval time.Duration, thresholds []time.Duration) *Reminder {
+	sorted := append([]time.Duration{}, thresholds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+	return &Reminder{
+		app:        app,
+		chatID:     chatID,
+		interval:   interval,
+		thresholds: sorted,
+	}
+}
+
+func (r *Reminder) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		r.check(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (r *Reminder) check(ctx context.Context) {
+	for _, chain := range r.app.chains {
+		r.checkChain(ctx, chain)
+	}
+}
+
+func (r *Reminder) checkChain(ctx context.Context, chain Chain) {
+	ctx, cancel := context.WithTimeout(ctx, cmdTimeout)
+	defer cancel()
+	// voted proposals are left out
+	proposals, err := chain.Voter.GetVoting(ctx)
+	if err != nil {
+		log.Errorf("reminder failed to get %s proposals: %v", chain.ID, err)
+		return
+	}
+	for _, prop := range proposals {
+		rec, err := r.app.store.GetProposal(chain.ID, prop.Id)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			log.Errorf("reminder failed to get stored %s proposal %s, err: %v", chain.ID, prop.Id, err)
+			continue
+		}
+		crossed := r.crossedThresholds(time.Until(prop.VotingEndTime))
+		if crossed <= rec.Reminded {
+			continue
+		}
+		urgent := crossed == len(r.thresholds)
+		if err := r.app.SendReminder(ctx, chain, prop, r.chatID, urgent); err != nil {
+			log.Errorf("failed to send reminder for %s proposal %s, err: %v", chain.ID, prop.Id, err)
+			continue
+		}
+		err = r.app.store.UpdateProposal(chain.ID, prop.Id, func(rec *store.ProposalRecord) error {
+			rec.Reminded = crossed
+			return nil
+		})
+		if err != nil {
+			log.Errorf("reminder failed to store reminded %s proposal %s, err: %v", chain.ID, prop.Id, err)
+		}
+		log.Infof("sent reminder %d/%d for %s proposal: %s", crossed, len(r.thresholds), chain.ID, prop.Id)
+	}
+}
+
+// crossedThresholds returns how many thresholds are not less than time left
+func (r *Reminder) crossedThresholds(left time.Duration) int {
+	crossed := 0
+	for _, threshold := range r.thresholds {
+		if left <= threshold {
+			crossed++
+		}
+	}
+	return crossed
+}
+
+func (app *App) SendReminder(ctx context.Context, chain Chain, prop vote.Proposal, chatID string, urgent bool) error {
+	text := fmt.Sprintf("Reminder: voting on %s proposal %s ends in %.2f hours", chain.Name, prop.Id, prop.DeadlineHrs)
+	if urgent {
+		text = fmt.Sprintf("URGENT: last reminder, voting on %s proposal %s ends in %.2f hours", chain.Name, prop.Id, prop.DeadlineHrs)
+	}
+	app.notifyProposal(ReminderSent, chain, prop, urgent)
+	if err := app.sendText(chatID, text); err != nil {
+		return errors.Wrap(err, "failed to send reminder")
+	}
+	return app.SendVotePrompt(ctx, chain, prop, chatID)
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReminderCrossedThresholds(t *testing.T) {
+	reminder := NewReminder(nil, "", time.Minute, []time.Duration{time.Hour, time.Hour * 24})
+	for _, tc := range []struct {
+		name    string
+		left    time.Duration
+		crossed int
+	}{
+		{"far", time.Hour * 48, 0},
+		{"at first threshold", time.Hour * 24, 1},
+		{"between thresholds", time.Hour * 3, 1},
+		{"at last threshold", time.Hour, 2},
+		{"ended", -time.Minute, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.crossed, reminder.crossedThresholds(tc.left))
+		})
+	}
+}
+
+func TestReminderCheck(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		left     time.Duration
+		reminded int
+		// reminder text, empty if none is sent
+		sent   string
+		stored int
+	}{
+		{"far", time.Hour * 48, 0, "", 0},
+		{"first", time.Hour * 3, 0, "Reminder: voting on Kujira proposal 291", 1},
+		{"first already sent", time.Hour * 3, 1, "", 1},
+		{"last is urgent", time.Minute * 30, 1, "URGENT: last reminder, voting on Kujira proposal 291", 2},
+		{"both crossed at once", time.Minute * 30, 0, "URGENT: last reminder", 2},
+		{"last already sent", time.Minute * 30, 2, "", 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			voter := vote.NewMockVoter(ctrl)
+			messenger := &fakeMessenger{}
+			chain := Chain{ID: "kaiyo-1", Name: "Kujira", Voter: voter}
+			app := NewApp([]Chain{chain}, messenger, nil, store.NewMemStore())
+			prop := vote.Proposal{Id: "291", Title: "GHOST", VotingEndTime: time.Now().Add(tc.left)}
+			app.recordProposal(chain, prop)
+			require.NoError(t, app.store.UpdateProposal("kaiyo-1", "291", func(rec *store.ProposalRecord) error {
+				rec.Reminded = tc.reminded
+				return nil
+			}))
+			// GetVoting leaves voted proposals out, so votes are not checked again
+			voter.EXPECT().GetVoting(gomock.Any()).Return([]vote.Proposal{prop}, nil)
+
+			NewReminder(app, "-100123", time.Minute, []time.Duration{time.Hour * 24, time.Hour}).check(context.Background())
+			if tc.sent == "" {
+				assert.Empty(t, messenger.sent)
+			} else {
+				require.Len(t, messenger.sent, 3)
+				assert.Contains(t, messenger.sent[0].text, tc.sent)
+				assert.NotEmpty(t, messenger.sent[2].buttons)
+			}
+			rec, err := app.store.GetProposal("kaiyo-1", "291")
+			require.NoError(t, err)
+			assert.Equal(t, tc.stored, rec.Reminded)
+		})
+	}
+}
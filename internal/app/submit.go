@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// submission is a vote decided in chat, it is cast off the receive loop as
+// broadcast and confirmation take up to VoteTimeout
+type submission struct {
+	press *ButtonPress
+	data  callbackData
+	chain Chain
+	// option or weighted options as shown to users
+	option    string
+	approvers []string
+	// reported along with the tx result
+	congrat string
+	// names the tx in errors, like vote or weighted vote
+	what string
+	cast func(ctx context.Context) (*vote.VoteResult, error)
+	// optional, called once the vote is cast
+	voted func()
+}
+
+// submitVote acknowledges the press at once and casts the vote in background,
+// the prompt is edited with the result when the tx is confirmed or fails
+func (app *App) submitVote(ctx context.Context, s submission) error {
+	key := pendingKey(s.chain.ID, s.data.propID)
+	if !app.startSubmitting(key) {
+		return app.notifyCallback(s.press, "A vote on this proposal is being submitted")
+	}
+
+	text := fmt.Sprintf("Submitting %s on %s proposal %s...", s.option, s.chain.Name, s.data.propID)
+	if err := app.messenger.Edit(s.press.Message, text, nil); err != nil {
+		app.doneSubmitting(key)
+		return errors.Wrapf(err, "failed to edit message with '%s'", text)
+	}
+	if err := app.messenger.Answer(s.press, "Submitting"); err != nil {
+		// the vote goes on, the button just keeps loading for a while
+		log.Errorf("failed to answer button press of %s: %v", s.press.From, err)
+	}
+	app.submissions.Add(1)
+	go func() {
+		defer app.submissions.Done()
+		defer app.doneSubmitting(key)
+		app.castSubmission(ctx, s)
+	}()
+	return nil
+}
+
+func (app *App) castSubmission(ctx context.Context, s submission) {
+	propID := s.data.propID
+	app.notifyVoteSubmitted(s.chain, propID, s.option, s.approvers)
+	ctx, cancel := context.WithTimeout(ctx, VoteTimeout)
+	defer cancel()
+	result, err := s.cast(ctx)
+	app.notifyVoteResult(s.chain, propID, s.option, s.approvers, result, err)
+	if err != nil {
+		log.Errorf("failed to %s %s on %s proposal %s, err: %v", s.what, s.option, s.chain.ID, propID, err)
+		text := fmt.Sprintf("Failed to process callback data '%s', err: %v", s.press.Data, voteFailed(err, s.what))
+		if err := app.messenger.Edit(s.press.Message, text, nil); err != nil {
+			log.Errorf("failed to edit message with '%s': %v", text, err)
+		}
+		return
+	}
+	app.clearApprovals(s.chain.ID, propID)
+	app.recordVote(s.press, s.chain.ID, propID, s.option, s.approvers, result.TxHash)
+	app.useCallback(s.data)
+	if s.voted != nil {
+		s.voted()
+	}
+	text := fmt.Sprintf("%s\n%s", s.congrat, result)
+	if err := app.messenger.Edit(s.press.Message, text, nil); err != nil {
+		log.Errorf("failed to edit message with '%s': %v", text, err)
+	}
+	log.Infof("voted %s on %s proposal %s", s.option, s.chain.ID, propID)
+}
+
+// startSubmitting is false if a vote on the proposal is already being cast
+func (app *App) startSubmitting(key string) bool {
+	app.submittingMtx.Lock()
+	defer app.submittingMtx.Unlock()
+	if app.submitting[key] {
+		return false
+	}
+	app.submitting[key] = true
+	return true
+}
+
+func (app *App) isSubmitting(key string) bool {
+	app.submittingMtx.Lock()
+	defer app.submittingMtx.Unlock()
+	return app.submitting[key]
+}
+
+func (app *App) doneSubmitting(key string) {
+	app.submittingMtx.Lock()
+	defer app.submittingMtx.Unlock()
+	delete(app.submitting, key)
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventMessenger delivers events to the handler and fails every answer
+type eventMessenger struct {
+	fakeMessenger
+	events []Event
+}
+
+func (m *eventMessenger) Receive(ctx context.Context, handler func(Event) error) error {
+	for _, event := range m.events {
+		if err := handler(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *eventMessenger) Answer(press *ButtonPress, text string) error {
+	return errors.New("query is too old")
+}
+
+func TestSubmitVoteOffReceiveLoop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	voter := vote.NewMockVoter(ctrl)
+	messenger := &fakeMessenger{}
+	chains := []Chain{{ID: "kaiyo-1", Name: "Kujira", Voter: voter}}
+	principals := []Principal{{ID: "1", Name: "alice", Role: RoleVoter}}
+	app := NewApp(chains, messenger, principals, store.NewMemStore())
+	prop := vote.Proposal{Id: "291", Title: "GHOST", VotingEndTime: time.Now().Add(time.Hour)}
+	require.NoError(t, app.SendVotePrompt(context.Background(), chains[0], prop, "-100123"))
+	keyboard := messenger.sent[1]
+	press := &ButtonPress{From: &User{ID: "1"}, Message: keyboard.ref, Data: keyboard.buttons[0].Data}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	voter.EXPECT().Vote(gomock.Any(), "291", vote.VoteOptionYes).DoAndReturn(
+		func(context.Context, string, vote.VoteOption) (*vote.VoteResult, error) {
+			close(started)
+			<-release
+			return &vote.VoteResult{TxHash: "ABCD"}, nil
+		})
+	// the press returns while the tx is being confirmed
+	require.NoError(t, app.ProcessCallback(context.Background(), press))
+	<-started
+	assert.Equal(t, "Submitting yes on Kujira proposal 291...", messenger.edited[0].text)
+	assert.Equal(t, []string{"Submitting"}, messenger.answered)
+
+	// a second press does not cast another vote
+	require.NoError(t, app.ProcessCallback(context.Background(), press))
+	assert.Equal(t, "A vote on this proposal is being submitted", messenger.answered[1])
+
+	close(release)
+	app.submissions.Wait()
+	require.Len(t, messenger.edited, 2)
+	assert.Contains(t, messenger.edited[1].text, "You voted yes on Kujira proposal 291")
+}
+
+func TestRunSurvivesFailedAnswer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	voter := vote.NewMockVoter(ctrl)
+	chains := []Chain{{ID: "kaiyo-1", Name: "Kujira", Voter: voter}}
+	principals := []Principal{{ID: "1", Name: "alice", Role: RoleViewer}}
+	messenger := &eventMessenger{events: []Event{
+		{Press: &ButtonPress{From: &User{ID: "stranger"}, Data: "forged"}},
+		{Command: &Command{ChatID: "-100123", From: &User{ID: "1"}, Name: "start"}},
+	}}
+	app := NewApp(chains, messenger, principals, store.NewMemStore())
+	prop := vote.Proposal{Id: "291", Title: "GHOST", VotingEndTime: time.Now().Add(time.Hour)}
+	voter.EXPECT().GetVoting(gomock.Any()).Return([]vote.Proposal{prop}, nil)
+
+	require.NoError(t, app.Run(context.Background()))
+	// the command after the failed answer is still handled
+	assert.Len(t, messenger.sent, 2)
+}
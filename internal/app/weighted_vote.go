@@ -0,0 +1,159 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	confirmAction     = "c"
+	cancelAction      = "x"
+	weightedVoteTTL   = time.Hour
+	weightedVoteUsage = "usage: /wvote [chain id] <proposal id> yes=0.7,abstain=0.3, chain id may be omitted with a single chain"
+)
+
+// ProcessWeightedVoteCommand handles '/wvote [chain] <id> <options>' and asks to confirm the split vote
+func (app *App) ProcessWeightedVoteCommand(ctx context.Context, cmd *Command) error {
+	args := strings.Fields(cmd.Args)
+	if len(args) == 2 && len(app.chains) == 1 {
+		args = append([]string{app.chains[0].ID}, args...)
+	}
+	if len(args) != 3 {
+		return app.reportCommandErr(cmd, errors.New(weightedVoteUsage))
+	}
+	chain, err := app.chain(args[0])
+	if err != nil {
+		return app.reportCommandErr(cmd, err)
+	}
+	propID := args[1]
+	options, err := vote.ParseWeightedVoteOptions(args[2])
+	if err != nil {
+		return app.reportCommandErr(cmd, errors.Wrap(err, "invalid weighted vote options"))
+	}
+	nonce, err := newCallbackNonce()
+	if err != nil {
+		return err
+	}
+	keyboard, err := app.weightedVoteKeyboard(chain.ID, propID, nonce, time.Now().Add(weightedVoteTTL))
+	if err != nil {
+		return app.reportCommandErr(cmd, err)
+	}
+	app.pendingWeightedMtx.Lock()
+	app.pendingWeighted[nonce] = options
+	app.pendingWeightedMtx.Unlock()
+
+	text := fmt.Sprintf("Confirm weighted vote %s on %s proposal %s", options, chain.Name, propID)
+	if fee := app.expectedFee(ctx, chain, propID); fee != "" {
+		text += fmt.Sprintf("\nExpected fee: %s", fee)
+	}
+	sent, err := app.messenger.Send(cmd.ChatID, text, keyboard)
+	if err != nil {
+		return errors.Wrap(err, "failed to send weighted vote confirmation")
+	}
+	app.recordPrompt(chain.ID, propID, nonce, sent)
+	log.Infof("asked to confirm weighted vote %s on %s proposal %s", options, chain.ID, propID)
+	return nil
+}
+
+// ProcessWeightedVoteCallback votes pending weighted options once confirmed,
+// data is verified by ProcessCallback
+func (app *App) ProcessWeightedVoteCallback(ctx context.Context, press *ButtonPress, data callbackData) error {
+	propID := data.propID
+	chain, err := app.chain(data.chainID)
+	if err != nil {
+		return app.reportCallbackErr(press, err)
+	}
+	if !app.isApprover(press.From) {
+		return app.notifyCallback(press, "You are not an approver")
+	}
+	app.pendingWeightedMtx.Lock()
+	options, ok := app.pendingWeighted[data.nonce]
+	app.pendingWeightedMtx.Unlock()
+	if !ok {
+		return app.reportCallbackErr(press, fmt.Errorf("no pending weighted vote on %s proposal %s", chain.Name, propID))
+	}
+	switch data.action {
+	case cancelAction:
+		app.dropPendingWeighted(data.nonce)
+		app.useCallback(data)
+		return app.answerCallback(press, fmt.Sprintf("Weighted vote on %s proposal %s cancelled", chain.Name, propID))
+	case confirmAction:
+	default:
+		return app.reportCallbackErr(press, fmt.Errorf("action is not [confirm|cancel]"))
+	}
+	approvers := []string{app.displayName(press.From)}
+	congrat := fmt.Sprintf("You voted %s on %s proposal %s", options, chain.Name, propID)
+	if app.requiredApprovals > 1 {
+		approval, err := app.approve(chain.ID, propID, press.From, options.String())
+		if err != nil {
+			return app.reportCallbackErr(press, err)
+		}
+		if approval.approved == "" {
+			keyboard, err := app.weightedVoteKeyboard(chain.ID, propID, data.nonce, data.expiry)
+			if err != nil {
+				return app.reportCallbackErr(press, err)
+			}
+			return app.reportApprovals(press, chain, propID, approval, keyboard)
+		}
+		approvers = approval.byOption[approval.approved]
+		congrat = fmt.Sprintf("Approved by %s, voted %s on %s proposal %s",
+			strings.Join(approvers, ", "), options, chain.Name, propID)
+	}
+	return app.submitVote(ctx, submission{
+		press:     press,
+		data:      data,
+		chain:     chain,
+		option:    options.String(),
+		approvers: approvers,
+		congrat:   congrat,
+		what:      "weighted vote",
+		cast: func(ctx context.Context) (*vote.VoteResult, error) {
+			return chain.Voter.WeightedVote(ctx, propID, options)
+		},
+		// options stay for confirming again if the tx fails
+		voted: func() {
+			app.dropPendingWeighted(data.nonce)
+		},
+	})
+}
+
+func (app *App) weightedVoteKeyboard(chainID string, propID string, nonce string, expiry time.Time) ([]Button, error) {
+	buttons := []Button{}
+	for _, button := range []struct {
+		text   string
+		action string
+	}{
+		{"Confirm", confirmAction},
+		{"Cancel", cancelAction},
+	} {
+		data, err := app.signCallback(callbackData{
+			kind:    callbackKindWeighted,
+			action:  button.action,
+			chainID: chainID,
+			propID:  propID,
+			nonce:   nonce,
+			expiry:  expiry,
+		})
+		if err != nil {
+			return nil, err
+		}
+		buttons = append(buttons, Button{Text: button.text, Data: data})
+	}
+	return buttons, nil
+}
+
+func (app *App) dropPendingWeighted(nonce string) {
+	app.pendingWeightedMtx.Lock()
+	delete(app.pendingWeighted, nonce)
+	app.pendingWeightedMtx.Unlock()
+}
+
+func pendingKey(chainID string, propID string) string {
+	return chainID + "/" + propID
+}
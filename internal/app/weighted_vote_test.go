@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/kostage/cosmos_voter/internal/store"
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const confirmButton = 0
+
+func newWeightedVoteTest(t *testing.T) (*App, *vote.MockVoter, *fakeMessenger) {
+	ctrl := gomock.NewController(t)
+	voter := vote.NewMockVoter(ctrl)
+	messenger := &fakeMessenger{}
+	chains := []Chain{{ID: "kaiyo-1", Name: "Kujira", Voter: voter}}
+	principals := []Principal{{ID: "1", Name: "alice", Role: RoleVoter}}
+	return NewApp(chains, messenger, principals, store.NewMemStore()), voter, messenger
+}
+
+func pressWeighted(t *testing.T, app *App, keyboard sentMessage, button int) {
+	press := &ButtonPress{From: &User{ID: "1"}, Message: keyboard.ref, Data: keyboard.buttons[button].Data}
+	require.NoError(t, app.ProcessCallback(context.Background(), press))
+	app.submissions.Wait()
+}
+
+func TestWeightedVoteKeepsEachPrompt(t *testing.T) {
+	app, voter, messenger := newWeightedVoteTest(t)
+	ask := func(options string) sentMessage {
+		cmd := &Command{ChatID: "-100123", From: &User{ID: "1"}, Name: "wvote", Args: "291 " + options}
+		require.NoError(t, app.ProcessWeightedVoteCommand(context.Background(), cmd))
+		return messenger.sent[len(messenger.sent)-1]
+	}
+	first := ask("yes=0.7,abstain=0.3")
+	ask("no=1")
+
+	// the second command does not replace the options confirmed by the first prompt
+	options, err := vote.ParseWeightedVoteOptions("yes=0.7,abstain=0.3")
+	require.NoError(t, err)
+	voter.EXPECT().WeightedVote(gomock.Any(), "291", options).Return(&vote.VoteResult{TxHash: "ABCD"}, nil)
+	pressWeighted(t, app, first, confirmButton)
+	assert.Contains(t, messenger.edited[len(messenger.edited)-1].text, "You voted yes=0.7,abstain=0.3")
+}
+
+func TestWeightedVoteRetryAfterFailure(t *testing.T) {
+	app, voter, messenger := newWeightedVoteTest(t)
+	cmd := &Command{ChatID: "-100123", From: &User{ID: "1"}, Name: "wvote", Args: "291 yes=1"}
+	require.NoError(t, app.ProcessWeightedVoteCommand(context.Background(), cmd))
+	keyboard := messenger.sent[len(messenger.sent)-1]
+
+	voter.EXPECT().WeightedVote(gomock.Any(), "291", gomock.Any()).Return(nil, errors.New("out of gas"))
+	pressWeighted(t, app, keyboard, confirmButton)
+	assert.Contains(t, messenger.edited[len(messenger.edited)-1].text, "out of gas")
+
+	voter.EXPECT().WeightedVote(gomock.Any(), "291", gomock.Any()).Return(&vote.VoteResult{TxHash: "ABCD"}, nil)
+	pressWeighted(t, app, keyboard, confirmButton)
+	assert.Contains(t, messenger.edited[len(messenger.edited)-1].text, "You voted yes=1")
+	assert.Empty(t, app.pendingWeighted)
+}
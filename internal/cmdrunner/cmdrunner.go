@@ -47,25 +47,32 @@ func (c *cmdRunner) Run(
 	}
 	var cmdErr error
 	wg := sync.WaitGroup{}
+	// cmd.Wait closes pipes, so it must not be called before reads complete
+	readWg := sync.WaitGroup{}
+	readsDone := make(chan struct{})
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		cmdErr = c.wait(ctx)
+		cmdErr = c.wait(ctx, readsDone)
 	}()
-	wg.Add(1)
+	readWg.Add(1)
 	go func() {
-		defer wg.Done()
+		defer readWg.Done()
 		if err := c.readStdOut(); err != nil {
 			log.Errorf("command '%s, %v' stdout stream failed: %v", command, args, err)
 		}
 	}()
-	wg.Add(1)
+	readWg.Add(1)
 	go func() {
-		defer wg.Done()
+		defer readWg.Done()
 		if err := c.readStdErr(); err != nil {
 			log.Errorf("command '%s, %v' stder stream failed: %v", command, args, err)
 		}
 	}()
+	go func() {
+		readWg.Wait()
+		close(readsDone)
+	}()
 	if input != nil {
 		wg.Add(1)
 		go func() {
@@ -111,7 +118,9 @@ func (c *cmdRunner) start(
 	return nil
 }
 
-func (c *cmdRunner) wait(ctx context.Context) error {
+// wait returns command result once stdout and stderr are read to the end,
+// command is terminated on context cancel closing its streams
+func (c *cmdRunner) wait(ctx context.Context, readsDone <-chan struct{}) error {
 	var cmdErr error
 	cmdErrCh := make(chan error)
 	cmdWg := sync.WaitGroup{}
@@ -119,6 +128,7 @@ func (c *cmdRunner) wait(ctx context.Context) error {
 	defer cmdWg.Wait()
 	go func() {
 		defer cmdWg.Done()
+		<-readsDone
 		cmdErrCh <- c.cmd.Wait()
 	}()
 
@@ -2,6 +2,7 @@ package cmdrunner
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -101,3 +102,18 @@ func TestCmd_SuccessReuse(t *testing.T) {
 	assert.Equal(t, "hello\n", string(stdout))
 	assert.Equal(t, "darkness, my old friend\n", string(stderr))
 }
+
+func TestCmd_WaitsForLargeOutput(t *testing.T) {
+	// cmd.Wait closes the pipes, so output exceeding pipe buffers must be read
+	// to the end on both streams before the command is reaped
+	r := NewCmdRunner()
+	stdoutSize, stderrSize := 1<<20, 100000
+	stdout, stderr, err := r.Run(
+		context.Background(),
+		"sh", []string{"-c", "head -c 1048576 /dev/zero | tr '\\0' a && head -c 100000 /dev/zero | tr '\\0' b >& 2"},
+		nil,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("a", stdoutSize), string(stdout))
+	assert.Equal(t, strings.Repeat("b", stderrSize), string(stderr))
+}
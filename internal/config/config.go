@@ -1,23 +1,158 @@
 package config
 
 import (
+	"bytes"
+	"io"
 	"os"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
 
+const (
+	QueryBackendCli  = "cli"
+	QueryBackendLcd  = "lcd"
+	QueryBackendGrpc = "grpc"
+
+	MessengerTelegram = "telegram"
+	MessengerSlack    = "slack"
+	MessengerDiscord  = "discord"
+	MessengerMatrix   = "matrix"
+
+	defaultListenAddr   = ":8080"
+	defaultPollInterval = time.Minute * 10
+)
+
 type Config struct {
-	BotToken     string `yaml:"bot_token"`
+	// telegram (default), slack, discord or matrix
+	Messenger string `yaml:"messenger"`
+	// telegram bot token
+	BotToken string        `yaml:"bot_token"`
+	Slack    SlackConfig   `yaml:"slack"`
+	Discord  DiscordConfig `yaml:"discord"`
+	Matrix   MatrixConfig  `yaml:"matrix"`
+	// messenger users allowed to use the bot
+	Users []UserConfig `yaml:"users"`
+	// single chain may be described at top level, several ones go to chains
+	ChainConfig `yaml:",inline"`
+	Chains      []ChainConfig `yaml:"chains"`
+	// chat to announce new proposals to (telegram chat, slack or discord
+	// channel or matrix room id), poller is disabled if empty
+	ChatID string `yaml:"chat_id"`
+	// 10m if chat_id is set
+	PollInterval time.Duration `yaml:"poll_interval"`
+	// unvoted proposals are reminded of when voting end is closer than each threshold
+	ReminderThresholds []time.Duration `yaml:"reminder_thresholds"`
+	// messenger user ids whose button presses count as vote approvals, any voter if empty
+	Approvers []string `yaml:"approvers"`
+	// approvals of the same option needed to vote, 1 if unset
+	RequiredApprovals int `yaml:"required_approvals"`
+	// bbolt file keeping proposals, prompts and votes, state is kept in memory if empty
+	StorePath string `yaml:"store_path"`
+	// auto-vote rules file, every proposal is prompted if empty
+	PolicyPath string `yaml:"policy_path"`
+	// only report what policy would have voted and prompt anyway
+	PolicyDryRun bool `yaml:"policy_dry_run"`
+	// signs vote buttons, random per start if empty so buttons sent before restart stop working
+	CallbackSecret string `yaml:"callback_secret"`
+	// chat is alerted when a voter wallet can pay fees of fewer votes, disabled if 0
+	LowBalanceVotes int `yaml:"low_balance_votes"`
+	// governance events are POSTed to webhook urls, disabled if there are none
+	Webhook WebhookConfig `yaml:"webhook"`
+	// proposals are mailed if smtp_addr and to are set
+	Email EmailConfig `yaml:"email"`
+}
+
+// WebhookConfig of json event deliveries signed with secret, events still
+// failing after max_attempts (5 if unset) are appended to dead_letter_file
+type WebhookConfig struct {
+	URLs           []string `yaml:"urls"`
+	Secret         string   `yaml:"secret"`
+	DeadLetterFile string   `yaml:"dead_letter_file"`
+	MaxAttempts    int      `yaml:"max_attempts"`
+}
+
+// SlackConfig of a slack app with a bot token, interactivity and slash
+// commands request urls of the app point to listen_addr
+type SlackConfig struct {
+	BotToken      string `yaml:"bot_token"`
+	SigningSecret string `yaml:"signing_secret"`
+	ListenAddr    string `yaml:"listen_addr"`
+}
+
+// DiscordConfig of a discord application, its interactions endpoint url
+// points to listen_addr
+type DiscordConfig struct {
+	BotToken string `yaml:"bot_token"`
+	// hex public key of the application
+	PublicKey  string `yaml:"public_key"`
+	ListenAddr string `yaml:"listen_addr"`
+}
+
+// MatrixConfig of a bot account joined to the rooms
+type MatrixConfig struct {
+	HomeserverURL string `yaml:"homeserver_url"`
+	AccessToken   string `yaml:"access_token"`
+	UserID        string `yaml:"user_id"`
+}
+
+// EmailConfig of an smtp server, STARTTLS is used if the server offers it
+type EmailConfig struct {
+	// host:port
+	SMTPAddr string `yaml:"smtp_addr"`
+	// no authentication if empty
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+	// mail unvoted proposals every day at digest_at UTC time of day, like 9h
+	DailyDigest bool          `yaml:"daily_digest"`
+	DigestAt    time.Duration `yaml:"digest_at"`
+	// mail proposals as soon as the poller detects them
+	NewProposals bool `yaml:"new_proposals"`
+}
+
+type UserConfig struct {
+	// messenger user id (numeric for telegram), usernames may change
+	ID   string `yaml:"id"`
+	Name string `yaml:"name"`
+	// viewer lists proposals, voter also votes, admin also changes settings
+	Role string `yaml:"role"`
+}
+
+type ChainConfig struct {
+	// name shown in prompts, chain id if empty
+	DisplayName  string `yaml:"display_name"`
 	VoterWallet  string `yaml:"voter_wallet"`
 	KeyChainPass string `yaml:"keychain_password"`
-	DaemonPath   string `yaml:"deamon_path"`
-	AllowedUser  string `yaml:"allowed_user"`
-	Fees         string `yaml:"fees"`
-	ChainId      string `yaml:"chain_id"`
+	// daemon binary signing and broadcasting votes
+	DaemonPath string `yaml:"daemon_path"`
+	// misspelled daemon_path of older configs
+	DeprecatedDaemonPath string `yaml:"deamon_path"`
+	Fees                 string `yaml:"fees"`
+	ChainId              string `yaml:"chain_id"`
+	// gov queries backend: cli (default, through the daemon binary), lcd or grpc
+	QueryBackend string `yaml:"query_backend"`
+	LcdURL       string `yaml:"lcd_url"`
+	GrpcAddr     string `yaml:"grpc_addr"`
+	GrpcTLS      bool   `yaml:"grpc_tls"`
+	// option cast if nobody voted fallback_before voting end, disabled if empty
+	FallbackVote   string        `yaml:"fallback_vote"`
+	FallbackBefore time.Duration `yaml:"fallback_before"`
+	// account voter_wallet votes for through authz exec, voter_wallet must be granted
+	// MsgVote and MsgVoteWeighted by it, votes are sent directly if empty
+	AuthzGranter string `yaml:"authz_granter"`
+	// gas is simulated and paid at gas_prices instead of static fees if set,
+	// gas_adjustment defaults to 1.5, txs with estimated fee above max_fee are not sent
+	GasPrices     string  `yaml:"gas_prices"`
+	GasAdjustment float64 `yaml:"gas_adjustment"`
+	MaxFee        string  `yaml:"max_fee"`
 }
 
+// ParseConfig rejects unknown keys, applies COSMOS_VOTER_* environment
+// overrides and defaults, then validates the result reporting every problem
 func ParseConfig(path string) (*Config, error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
@@ -25,9 +160,64 @@ func ParseConfig(path string) (*Config, error) {
 		return nil, errors.Wrapf(err, "failed to read file %s", path)
 	}
 	conf := &Config{}
-	if err := yaml.Unmarshal(content, conf); err != nil {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	// empty file decodes to io.EOF
+	if err := decoder.Decode(conf); err != nil && err != io.EOF {
 		log.Errorf("failed to parse config file %s due to %v", path, err)
 		return nil, errors.Wrapf(err, "failed to parse config file %s", path)
 	}
+	problems := conf.ApplyEnv(os.LookupEnv)
+	conf.SetDefaults()
+	problems = append(problems, conf.problems()...)
+	if len(problems) > 0 {
+		err := &ValidationError{Problems: problems}
+		log.Errorf("invalid config file %s: %v", path, err)
+		return nil, errors.Wrapf(err, "invalid config file %s", path)
+	}
 	return conf, nil
 }
+
+// SetDefaults fills settings left empty
+func (c *Config) SetDefaults() {
+	if c.Messenger == "" {
+		c.Messenger = MessengerTelegram
+	}
+	if c.Slack.ListenAddr == "" {
+		c.Slack.ListenAddr = defaultListenAddr
+	}
+	if c.Discord.ListenAddr == "" {
+		c.Discord.ListenAddr = defaultListenAddr
+	}
+	if c.ChatID != "" && c.PollInterval == 0 {
+		c.PollInterval = defaultPollInterval
+	}
+	if c.RequiredApprovals == 0 {
+		c.RequiredApprovals = 1
+	}
+	c.ChainConfig.setDefaults()
+	for i := range c.Chains {
+		c.Chains[i].setDefaults()
+	}
+}
+
+func (c *ChainConfig) setDefaults() {
+	if c.DaemonPath == "" && c.DeprecatedDaemonPath != "" {
+		log.Warnf("deamon_path of chain %s is deprecated, rename it to daemon_path", c.ChainId)
+		c.DaemonPath = c.DeprecatedDaemonPath
+	}
+	if c.QueryBackend == "" {
+		c.QueryBackend = QueryBackendCli
+	}
+	if c.DisplayName == "" {
+		c.DisplayName = c.ChainId
+	}
+}
+
+// AllChains returns chains list or the top level chain if the list is empty
+func (c *Config) AllChains() []ChainConfig {
+	if len(c.Chains) == 0 {
+		return []ChainConfig{c.ChainConfig}
+	}
+	return c.Chains
+}
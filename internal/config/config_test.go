@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfig writes content and an executable kujirad next to it, content
+// refers to the daemon as DAEMON
+func writeConfig(t *testing.T, content string) string {
+	dir := t.TempDir()
+	daemon := filepath.Join(dir, "kujirad")
+	require.NoError(t, os.WriteFile(daemon, []byte("#!/bin/sh\n"), 0755))
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(os.Expand(content, func(string) string { return daemon })), 0600))
+	return path
+}
+
+func validationProblems(t *testing.T, err error) []string {
+	validationErr := &ValidationError{}
+	require.True(t, errors.As(err, &validationErr), "not a validation error: %v", err)
+	return validationErr.Problems
+}
+
+func TestParseConfig(t *testing.T) {
+	path := writeConfig(t, `
+bot_token: "123:abc"
+users:
+  - id: 123456789
+    name: kostage
+    role: admin
+chat_id: "-100123"
+chains:
+  - voter_wallet: kujira1voter
+    deamon_path: ${DAEMON}
+    fees: 250ukuji
+    chain_id: kaiyo-1
+`)
+	t.Setenv("COSMOS_VOTER_CHAINS_0_KEYCHAIN_PASSWORD", "secret")
+	t.Setenv("COSMOS_VOTER_APPROVERS", "123456789, 987654321")
+	t.Setenv("COSMOS_VOTER_REMINDER_THRESHOLDS", "24h,1h")
+	conf, err := ParseConfig(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "123456789", conf.Users[0].ID)
+	assert.Equal(t, "secret", conf.Chains[0].KeyChainPass)
+	assert.Equal(t, []string{"123456789", "987654321"}, conf.Approvers)
+	assert.Equal(t, []time.Duration{time.Hour * 24, time.Hour}, conf.ReminderThresholds)
+	// defaults
+	assert.Equal(t, MessengerTelegram, conf.Messenger)
+	assert.Equal(t, defaultPollInterval, conf.PollInterval)
+	assert.Equal(t, 1, conf.RequiredApprovals)
+	assert.Equal(t, QueryBackendCli, conf.Chains[0].QueryBackend)
+	assert.Equal(t, "kaiyo-1", conf.Chains[0].DisplayName)
+	assert.Equal(t, conf.Chains[0].DeprecatedDaemonPath, conf.Chains[0].DaemonPath)
+}
+
+func TestParseConfigUnknownField(t *testing.T) {
+	path := writeConfig(t, `
+bot_tokn: "123:abc"
+`)
+	_, err := ParseConfig(path)
+	assert.ErrorContains(t, err, "field bot_tokn not found")
+}
+
+func TestParseConfigExample(t *testing.T) {
+	// example is strictly decoded, its empty secrets are reported
+	_, err := ParseConfig("../../config_example.yaml")
+	problems := validationProblems(t, err)
+	assert.Contains(t, problems, "bot_token is empty, required by telegram messenger")
+}
+
+func TestParseConfigProblems(t *testing.T) {
+	path := writeConfig(t, `
+messenger: discord
+discord:
+  bot_token: "token"
+  public_key: "not hex"
+approvers: ["1"]
+required_approvals: 2
+webhook:
+  urls: ["ftp://ops.example.com"]
+chains:
+  - chain_id: kaiyo-1
+    voter_wallet: kujira1voter
+    daemon_path: /nonexistent/kujirad
+    fees: 250 ukuji
+    query_backend: rest
+    fallback_vote: maybe
+  - chain_id: kaiyo-1
+    daemon_path: ${DAEMON}
+    fees: 250ukuji
+    gas_prices: 0.00125ukuji
+    gas_adjustment: 0.5
+    query_backend: grpc
+`)
+	t.Setenv("COSMOS_VOTER_POLL_INTERVAL", "soon")
+	_, err := ParseConfig(path)
+	problems := validationProblems(t, err)
+	assert.ElementsMatch(t, []string{
+		`COSMOS_VOTER_POLL_INTERVAL: time: invalid duration "soon"`,
+		"discord.public_key 'not hex' is not 32 hex bytes",
+		"users is empty, nobody could use the bot",
+		"chains[0].daemon_path: stat /nonexistent/kujirad: no such file or directory",
+		"chains[0].fees '250 ukuji' is not a coin like 250ukuji",
+		"chains[0].query_backend 'rest' is not cli, lcd or grpc",
+		"chains[0].fallback_vote: unknown vote option 'maybe'",
+		"chains[0].fallback_before is not positive while fallback_vote is set",
+		"chains[0].fallback_vote is set while chat_id is empty, fallback votes are never cast",
+		"chains[1].chain_id kaiyo-1 is duplicated",
+		"chains[1].voter_wallet is empty",
+		"chains[1].fees and gas_prices are both set, only one of them is allowed",
+		"chains[1].gas_adjustment 0.5 is less than 1",
+		"chains[1].grpc_addr '' is not host:port, required by grpc query backend",
+		"required_approvals 2 exceeds 1 approvers",
+		"webhook.urls[0] 'ftp://ops.example.com' is not an http url",
+	}, problems)
+	assert.Contains(t, err.Error(), "16 problems:\n  - ")
+}
+
+func TestParseConfigFallbackProblems(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		chatID   string
+		before   string
+		problems []string
+	}{
+		{"valid", "-100123", "30m", nil},
+		{"no chat", "", "30m", []string{"fallback_vote is set while chat_id is empty, fallback votes are never cast"}},
+		{"before poll", "-100123", "5m", []string{"fallback_before 5m0s is less than poll_interval 10m0s, voting may end between checks"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeConfig(t, fmt.Sprintf(`
+bot_token: "123:abc"
+users:
+  - id: 123456789
+    role: admin
+chat_id: "%s"
+chain_id: kaiyo-1
+voter_wallet: kujira1voter
+daemon_path: ${DAEMON}
+gas_prices: 0.00125ukuji
+fallback_vote: abstain
+fallback_before: %s
+`, tc.chatID, tc.before))
+			_, err := ParseConfig(path)
+			if tc.problems == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tc.problems, validationProblems(t, err))
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	EnvPrefix = "COSMOS_VOTER"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// ApplyEnv overrides settings with environment variables named by their yaml
+// path, like COSMOS_VOTER_BOT_TOKEN, COSMOS_VOTER_SLACK_SIGNING_SECRET or
+// COSMOS_VOTER_CHAINS_0_KEYCHAIN_PASSWORD for chains already in the file,
+// lists are comma separated, unparsable values are returned as problems
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) []string {
+	return applyEnv(EnvPrefix, reflect.ValueOf(c).Elem(), lookup)
+}
+
+func applyEnv(prefix string, v reflect.Value, lookup func(string) (string, bool)) []string {
+	problems := []string{}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		value := v.Field(i)
+		if opts == "inline" {
+			problems = append(problems, applyEnv(prefix, value, lookup)...)
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+		key := prefix + "_" + strings.ToUpper(name)
+		switch {
+		case value.Kind() == reflect.Struct:
+			problems = append(problems, applyEnv(key, value, lookup)...)
+		case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Struct:
+			for j := 0; j < value.Len(); j++ {
+				problems = append(problems, applyEnv(fmt.Sprintf("%s_%d", key, j), value.Index(j), lookup)...)
+			}
+		default:
+			env, ok := lookup(key)
+			if !ok {
+				continue
+			}
+			if err := setEnvValue(value, env); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+			}
+		}
+	}
+	return problems
+}
+
+func setEnvValue(v reflect.Value, env string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(env)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(env)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		i, err := strconv.Atoi(env)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(i))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(env, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		items := []string{}
+		for _, item := range strings.Split(env, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		list := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setEnvValue(list.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(list)
+	default:
+		return fmt.Errorf("%s can not be set from environment", v.Type())
+	}
+	return nil
+}
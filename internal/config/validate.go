@@ -0,0 +1,257 @@
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/vote"
+)
+
+var (
+	// sdk coin denom
+	denomPattern = `[a-zA-Z][a-zA-Z0-9/:._-]{2,127}`
+	coinRe       = regexp.MustCompile(`^[0-9]+` + denomPattern + `$`)
+	decCoinRe    = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?` + denomPattern + `$`)
+
+	userRoles = []string{"viewer", "voter", "admin"}
+)
+
+// ValidationError lists every problem of a config
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d problems:\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// Validate returns ValidationError if settings are missing or malformed
+func (c *Config) Validate() error {
+	if problems := c.problems(); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func (c *Config) problems() []string {
+	p := problemList{}
+	c.checkMessenger(&p)
+	if len(c.Users) == 0 {
+		p.add("users is empty, nobody could use the bot")
+	}
+	userIDs := map[string]bool{}
+	for i, user := range c.Users {
+		if user.ID == "" {
+			p.add("users[%d].id is empty", i)
+		} else if userIDs[user.ID] {
+			p.add("users[%d].id %s is duplicated", i, user.ID)
+		}
+		userIDs[user.ID] = true
+		if !contains(userRoles, user.Role) {
+			p.add("users[%d].role '%s' is not %s", i, user.Role, strings.Join(userRoles, ", "))
+		}
+	}
+	if len(c.Chains) > 0 && c.ChainConfig.ChainId != "" {
+		p.add("chain_id is set at top level while chains list is not empty, top level chain is ignored")
+	}
+	chainIDs := map[string]bool{}
+	for i, chain := range c.AllChains() {
+		prefix := ""
+		if len(c.Chains) > 0 {
+			prefix = fmt.Sprintf("chains[%d].", i)
+		}
+		if chain.ChainId != "" && chainIDs[chain.ChainId] {
+			p.add("%schain_id %s is duplicated", prefix, chain.ChainId)
+		}
+		chainIDs[chain.ChainId] = true
+		chain.check(&p, prefix)
+		c.checkFallback(&p, chain, prefix)
+	}
+	if c.PollInterval < 0 {
+		p.add("poll_interval %s is negative", c.PollInterval)
+	}
+	for _, threshold := range c.ReminderThresholds {
+		if threshold <= 0 {
+			p.add("reminder_thresholds %s is not positive", threshold)
+		}
+	}
+	if c.RequiredApprovals < 0 {
+		p.add("required_approvals %d is negative", c.RequiredApprovals)
+	}
+	if len(c.Approvers) > 0 && c.RequiredApprovals > len(c.Approvers) {
+		p.add("required_approvals %d exceeds %d approvers", c.RequiredApprovals, len(c.Approvers))
+	}
+	if c.LowBalanceVotes < 0 {
+		p.add("low_balance_votes %d is negative", c.LowBalanceVotes)
+	}
+	if c.PolicyPath != "" {
+		if _, err := os.Stat(c.PolicyPath); err != nil {
+			p.add("policy_path: %v", err)
+		}
+	}
+	c.checkWebhook(&p)
+	c.checkEmail(&p)
+	return p
+}
+
+func (c *Config) checkMessenger(p *problemList) {
+	switch c.Messenger {
+	case MessengerTelegram:
+		p.required(c.BotToken, "bot_token", c.Messenger)
+	case MessengerSlack:
+		p.required(c.Slack.BotToken, "slack.bot_token", c.Messenger)
+		p.required(c.Slack.SigningSecret, "slack.signing_secret", c.Messenger)
+	case MessengerDiscord:
+		p.required(c.Discord.BotToken, "discord.bot_token", c.Messenger)
+		if key, err := hex.DecodeString(c.Discord.PublicKey); err != nil || len(key) != 32 {
+			p.add("discord.public_key '%s' is not 32 hex bytes", c.Discord.PublicKey)
+		}
+	case MessengerMatrix:
+		p.required(c.Matrix.HomeserverURL, "matrix.homeserver_url", c.Messenger)
+		p.required(c.Matrix.AccessToken, "matrix.access_token", c.Messenger)
+		p.required(c.Matrix.UserID, "matrix.user_id", c.Messenger)
+	default:
+		p.add("messenger '%s' is not %s, %s, %s or %s",
+			c.Messenger, MessengerTelegram, MessengerSlack, MessengerDiscord, MessengerMatrix)
+	}
+}
+
+func (c *ChainConfig) check(p *problemList, prefix string) {
+	if c.ChainId == "" {
+		p.add("%schain_id is empty", prefix)
+	}
+	if c.VoterWallet == "" {
+		p.add("%svoter_wallet is empty", prefix)
+	}
+	if c.DaemonPath == "" {
+		p.add("%sdaemon_path is empty", prefix)
+	} else if info, err := os.Stat(c.DaemonPath); err != nil {
+		p.add("%sdaemon_path: %v", prefix, err)
+	} else if info.IsDir() || info.Mode().Perm()&0111 == 0 {
+		p.add("%sdaemon_path %s is not an executable file", prefix, c.DaemonPath)
+	}
+	if c.Fees == "" && c.GasPrices == "" {
+		p.add("%sfees and gas_prices are empty, one of them is required", prefix)
+	} else if c.Fees != "" && c.GasPrices != "" {
+		p.add("%sfees and gas_prices are both set, only one of them is allowed", prefix)
+	}
+	if c.Fees != "" && !isCoins(c.Fees, coinRe) {
+		p.add("%sfees '%s' is not a coin like 250ukuji", prefix, c.Fees)
+	}
+	if c.GasPrices != "" && !isCoins(c.GasPrices, decCoinRe) {
+		p.add("%sgas_prices '%s' is not a coin like 0.00125ukuji", prefix, c.GasPrices)
+	}
+	if c.GasAdjustment != 0 && c.GasAdjustment < 1 {
+		p.add("%sgas_adjustment %v is less than 1", prefix, c.GasAdjustment)
+	}
+	if c.MaxFee != "" && !coinRe.MatchString(c.MaxFee) {
+		p.add("%smax_fee '%s' is not a coin like 1000ukuji", prefix, c.MaxFee)
+	}
+	switch c.QueryBackend {
+	case QueryBackendCli:
+	case QueryBackendLcd:
+		if _, err := url.ParseRequestURI(c.LcdURL); err != nil {
+			p.add("%slcd_url '%s' is not a url, required by lcd query backend", prefix, c.LcdURL)
+		}
+	case QueryBackendGrpc:
+		if _, _, err := net.SplitHostPort(c.GrpcAddr); err != nil {
+			p.add("%sgrpc_addr '%s' is not host:port, required by grpc query backend", prefix, c.GrpcAddr)
+		}
+	default:
+		p.add("%squery_backend '%s' is not %s, %s or %s", prefix, c.QueryBackend, QueryBackendCli, QueryBackendLcd, QueryBackendGrpc)
+	}
+	if c.FallbackVote != "" {
+		if _, err := vote.ParseVoteOption(c.FallbackVote); err != nil {
+			p.add("%sfallback_vote: %v", prefix, err)
+		}
+		if c.FallbackBefore <= 0 {
+			p.add("%sfallback_before is not positive while fallback_vote is set", prefix)
+		}
+	}
+}
+
+// checkFallback covers fallback settings depending on chat and polling, fallback
+// votes are reported to chat and cast by a worker checking every poll_interval
+func (c *Config) checkFallback(p *problemList, chain ChainConfig, prefix string) {
+	if chain.FallbackVote == "" {
+		return
+	}
+	if c.ChatID == "" {
+		p.add("%sfallback_vote is set while chat_id is empty, fallback votes are never cast", prefix)
+	}
+	if chain.FallbackBefore > 0 && chain.FallbackBefore < c.PollInterval {
+		p.add("%sfallback_before %s is less than poll_interval %s, voting may end between checks",
+			prefix, chain.FallbackBefore, c.PollInterval)
+	}
+}
+
+func (c *Config) checkWebhook(p *problemList) {
+	for i, webhookURL := range c.Webhook.URLs {
+		u, err := url.Parse(webhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			p.add("webhook.urls[%d] '%s' is not an http url", i, webhookURL)
+		}
+	}
+	if c.Webhook.MaxAttempts < 0 {
+		p.add("webhook.max_attempts %d is negative", c.Webhook.MaxAttempts)
+	}
+}
+
+// checkEmail skips disabled email
+func (c *Config) checkEmail(p *problemList) {
+	if c.Email.SMTPAddr == "" || len(c.Email.To) == 0 {
+		return
+	}
+	if _, _, err := net.SplitHostPort(c.Email.SMTPAddr); err != nil {
+		p.add("email.smtp_addr '%s' is not host:port", c.Email.SMTPAddr)
+	}
+	if _, err := mail.ParseAddress(c.Email.From); err != nil {
+		p.add("email.from '%s' is not an address", c.Email.From)
+	}
+	for i, to := range c.Email.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			p.add("email.to[%d] '%s' is not an address", i, to)
+		}
+	}
+	if c.Email.DigestAt < 0 || c.Email.DigestAt >= time.Hour*24 {
+		p.add("email.digest_at %s is not a time of day", c.Email.DigestAt)
+	}
+}
+
+type problemList []string
+
+func (p *problemList) add(format string, args ...interface{}) {
+	*p = append(*p, fmt.Sprintf(format, args...))
+}
+
+func (p *problemList) required(value string, key string, messenger string) {
+	if value == "" {
+		p.add("%s is empty, required by %s messenger", key, messenger)
+	}
+}
+
+// isCoins checks comma separated coins like 250ukuji,1uatom
+func isCoins(s string, re *regexp.Regexp) bool {
+	for _, coin := range strings.Split(s, ",") {
+		if !re.MatchString(strings.TrimSpace(coin)) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,337 @@
+package discordbot
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/app"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultAPIURL = "https://discord.com/api/v10"
+
+	maxBodySize = 1 << 20
+	// discord limits
+	maxContentLen    = 2000
+	maxButtonsPerRow = 5
+	// presses and commands waiting for the app
+	eventsQueueSize = 100
+
+	// interaction types
+	interactionPing      = 1
+	interactionCommand   = 2
+	interactionComponent = 3
+	// interaction response types
+	responsePong           = 1
+	responseMessage        = 4
+	responseDeferredUpdate = 6
+	// component types
+	componentActionRow = 1
+	componentButton    = 2
+	buttonStylePrimary = 1
+	// message flag shown to the invoking user only
+	flagEphemeral = 64
+)
+
+// DiscordBot is the discord messenger of the app, messages are sent with the
+// REST api and slash commands and button presses are received by an http
+// server the application interactions endpoint url points to
+type DiscordBot struct {
+	token      string
+	publicKey  ed25519.PublicKey
+	listenAddr string
+	apiURL     string
+	client     *http.Client
+	events     chan app.Event
+}
+
+// NewDiscordBot takes bot token and hex public key of the application
+// verifying interaction requests
+func NewDiscordBot(token string, publicKey string, listenAddr string) (*DiscordBot, error) {
+	key, err := hex.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.Errorf("discord public key '%s' is not %d hex bytes", publicKey, ed25519.PublicKeySize)
+	}
+	return &DiscordBot{
+		token:      token,
+		publicKey:  key,
+		listenAddr: listenAddr,
+		apiURL:     defaultAPIURL,
+		client:     &http.Client{Timeout: time.Second * 15},
+		events:     make(chan app.Event, eventsQueueSize),
+	}, nil
+}
+
+type component struct {
+	Type       int         `json:"type"`
+	Components []component `json:"components,omitempty"`
+	Style      int         `json:"style,omitempty"`
+	Label      string      `json:"label,omitempty"`
+	CustomID   string      `json:"custom_id,omitempty"`
+}
+
+type messageRequest struct {
+	Content    string      `json:"content"`
+	Components []component `json:"components"`
+	Flags      int         `json:"flags,omitempty"`
+}
+
+type message struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+}
+
+type user struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type interaction struct {
+	Type          int    `json:"type"`
+	ApplicationID string `json:"application_id"`
+	Token         string `json:"token"`
+	ChannelID     string `json:"channel_id"`
+	Data          struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Name  string          `json:"name"`
+			Value json.RawMessage `json:"value"`
+		} `json:"options"`
+		CustomID string `json:"custom_id"`
+	} `json:"data"`
+	// member is set in guilds, user in direct messages
+	Member *struct {
+		User user `json:"user"`
+	} `json:"member"`
+	User    *user    `json:"user"`
+	Message *message `json:"message"`
+}
+
+type interactionResponse struct {
+	Type int             `json:"type"`
+	Data *messageRequest `json:"data,omitempty"`
+}
+
+func truncate(content string) string {
+	if len(content) <= maxContentLen {
+		return content
+	}
+	return strings.ToValidUTF8(content[:maxContentLen-3], "") + "..."
+}
+
+// components lays buttons out in rows, empty rows remove buttons on edit
+func components(buttons []app.Button) []component {
+	rows := []component{}
+	for i, button := range buttons {
+		if i%maxButtonsPerRow == 0 {
+			rows = append(rows, component{Type: componentActionRow})
+		}
+		row := &rows[len(rows)-1]
+		row.Components = append(row.Components, component{
+			Type:     componentButton,
+			Style:    buttonStylePrimary,
+			Label:    button.Text,
+			CustomID: button.Data,
+		})
+	}
+	return rows
+}
+
+func (b *DiscordBot) Send(chatID string, text string, buttons []app.Button) (app.MessageRef, error) {
+	sent := message{}
+	err := b.call(http.MethodPost, "/channels/"+url.PathEscape(chatID)+"/messages",
+		messageRequest{Content: truncate(text), Components: components(buttons)}, &sent)
+	if err != nil {
+		return app.MessageRef{}, errors.Wrap(err, "failed to send discord message")
+	}
+	return app.MessageRef{ChatID: sent.ChannelID, MessageID: sent.ID}, nil
+}
+
+func (b *DiscordBot) Edit(ref app.MessageRef, text string, buttons []app.Button) error {
+	err := b.call(http.MethodPatch, "/channels/"+url.PathEscape(ref.ChatID)+"/messages/"+url.PathEscape(ref.MessageID),
+		messageRequest{Content: truncate(text), Components: components(buttons)}, &message{})
+	if err != nil {
+		return errors.Wrap(err, "failed to edit discord message")
+	}
+	return nil
+}
+
+// Answer sends an ephemeral followup of the press interaction, presses are
+// acknowledged on receipt
+func (b *DiscordBot) Answer(press *app.ButtonPress, text string) error {
+	if text == "" {
+		return nil
+	}
+	// press id is <application id>/<interaction token>
+	err := b.call(http.MethodPost, "/webhooks/"+press.ID,
+		messageRequest{Content: truncate(text), Components: []component{}, Flags: flagEphemeral}, &message{})
+	if err != nil {
+		return errors.Wrap(err, "failed to answer discord button press")
+	}
+	return nil
+}
+
+func (b *DiscordBot) call(method string, path string, request interface{}, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, b.apiURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bot "+b.token)
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("%s %s returned status %s: %s", method, path, resp.Status, string(respBody))
+	}
+	if err := json.Unmarshal(respBody, response); err != nil {
+		return errors.Wrapf(err, "failed to decode %s %s response", method, path)
+	}
+	return nil
+}
+
+func (b *DiscordBot) Receive(ctx context.Context, handler func(app.Event) error) error {
+	server := &http.Server{Addr: b.listenAddr, Handler: b}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+	defer server.Close()
+	for {
+		select {
+		case event := <-b.events:
+			if err := handler(event); err != nil {
+				return err
+			}
+		case err := <-serverErr:
+			return errors.Wrap(err, "discord interactions server stopped")
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// ServeHTTP answers interactions at once as discord waits for 3 seconds only,
+// commands and presses are passed to the app afterwards
+func (b *DiscordBot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if !b.verify(r.Header, body) {
+		log.Error("rejected discord interaction with invalid signature")
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+	request := interaction{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, "invalid interaction", http.StatusBadRequest)
+		return
+	}
+	response := interactionResponse{Type: responsePong}
+	event := app.Event{}
+	switch request.Type {
+	case interactionPing:
+	case interactionCommand:
+		event.Command = request.command()
+		response = interactionResponse{
+			Type: responseMessage,
+			Data: &messageRequest{Content: "/" + request.Data.Name, Flags: flagEphemeral},
+		}
+	case interactionComponent:
+		if request.Message == nil {
+			http.Error(w, "no message of component", http.StatusBadRequest)
+			return
+		}
+		event.Press = request.press()
+		response = interactionResponse{Type: responseDeferredUpdate}
+	default:
+		http.Error(w, "unsupported interaction", http.StatusBadRequest)
+		return
+	}
+	if event.Command != nil || event.Press != nil {
+		select {
+		case b.events <- event:
+		default:
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Errorf("failed to respond discord interaction: %v", err)
+	}
+}
+
+// verify checks ed25519 signature of timestamp and body
+func (b *DiscordBot) verify(header http.Header, body []byte) bool {
+	signature, err := hex.DecodeString(header.Get("X-Signature-Ed25519"))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return false
+	}
+	signed := append([]byte(header.Get("X-Signature-Timestamp")), body...)
+	return ed25519.Verify(b.publicKey, signed, signature)
+}
+
+func (i *interaction) user() *app.User {
+	from := i.User
+	if i.Member != nil {
+		from = &i.Member.User
+	}
+	if from == nil {
+		return nil
+	}
+	return &app.User{ID: from.ID, Name: from.Username}
+}
+
+// command joins option values as command arguments
+func (i *interaction) command() *app.Command {
+	args := make([]string, 0, len(i.Data.Options))
+	for _, option := range i.Data.Options {
+		var value interface{}
+		if err := json.Unmarshal(option.Value, &value); err != nil {
+			continue
+		}
+		if s, ok := value.(string); ok {
+			args = append(args, s)
+			continue
+		}
+		args = append(args, string(option.Value))
+	}
+	return &app.Command{
+		ChatID: i.ChannelID,
+		From:   i.user(),
+		Name:   i.Data.Name,
+		Args:   strings.Join(args, " "),
+	}
+}
+
+func (i *interaction) press() *app.ButtonPress {
+	return &app.ButtonPress{
+		ID:      i.ApplicationID + "/" + i.Token,
+		From:    i.user(),
+		Message: app.MessageRef{ChatID: i.Message.ChannelID, MessageID: i.Message.ID},
+		Data:    i.Data.CustomID,
+	}
+}
@@ -0,0 +1,136 @@
+package discordbot
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kostage/cosmos_voter/internal/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBot(t *testing.T) (*DiscordBot, ed25519.PrivateKey) {
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	bot, err := NewDiscordBot("bot-token", hex.EncodeToString(public), "")
+	require.NoError(t, err)
+	return bot, private
+}
+
+// interact posts signed interaction to bot and decodes the response
+func interact(t *testing.T, url string, key ed25519.PrivateKey, body string) (int, interactionResponse) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+	timestamp := "1700000000"
+	req.Header.Set("X-Signature-Timestamp", timestamp)
+	req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(key, []byte(timestamp+body))))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	response := interactionResponse{}
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	}
+	return resp.StatusCode, response
+}
+
+func TestNewDiscordBotInvalidKey(t *testing.T) {
+	_, err := NewDiscordBot("bot-token", "not hex", "")
+	assert.Error(t, err)
+}
+
+func TestSendAndEdit(t *testing.T) {
+	requests := map[string]messageRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bot bot-token", r.Header.Get("Authorization"))
+		request := messageRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		requests[r.Method+" "+r.URL.Path] = request
+		if r.URL.Path == "/channels/404/messages" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Unknown Channel", "code": 10003}`))
+			return
+		}
+		w.Write([]byte(`{"id": "1170000000000000001", "channel_id": "1160000000000000001"}`))
+	}))
+	defer server.Close()
+	bot, _ := testBot(t)
+	bot.apiURL = server.URL
+
+	buttons := []app.Button{}
+	for _, text := range []string{"Yes", "No", "Abstain", "Veto", "Skip", "Extra"} {
+		buttons = append(buttons, app.Button{Text: text, Data: "v " + text})
+	}
+	ref, err := bot.Send("1160000000000000001", "Kujira proposal 291", buttons)
+	require.NoError(t, err)
+	assert.Equal(t, app.MessageRef{ChatID: "1160000000000000001", MessageID: "1170000000000000001"}, ref)
+	sent := requests["POST /channels/1160000000000000001/messages"]
+	assert.Equal(t, "Kujira proposal 291", sent.Content)
+	// discord allows 5 buttons per row
+	require.Len(t, sent.Components, 2)
+	assert.Len(t, sent.Components[0].Components, 5)
+	assert.Equal(t, "Yes", sent.Components[0].Components[0].Label)
+	assert.Equal(t, "v Yes", sent.Components[0].Components[0].CustomID)
+
+	require.NoError(t, bot.Edit(ref, "You voted yes", nil))
+	edited, ok := requests["PATCH /channels/1160000000000000001/messages/1170000000000000001"]
+	require.True(t, ok)
+	assert.Empty(t, edited.Components)
+
+	require.NoError(t, bot.Answer(&app.ButtonPress{ID: "app/interaction-token"}, "Approval recorded"))
+	answer := requests["POST /webhooks/app/interaction-token"]
+	assert.Equal(t, "Approval recorded", answer.Content)
+	assert.Equal(t, flagEphemeral, answer.Flags)
+
+	_, err = bot.Send("404", "text", nil)
+	assert.ErrorContains(t, err, "Unknown Channel")
+}
+
+func TestReceiveInteractions(t *testing.T) {
+	bot, key := testBot(t)
+	server := httptest.NewServer(bot)
+	defer server.Close()
+
+	status, response := interact(t, server.URL, key, `{"type": 1}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, responsePong, response.Type)
+
+	status, response = interact(t, server.URL, key, `{"type": 2, "channel_id": "1160000000000000001",
+		"member": {"user": {"id": "80351110224678912", "username": "kostage"}},
+		"data": {"name": "wvote", "options": [{"name": "proposal", "value": 291}, {"name": "options", "value": "yes=0.7,abstain=0.3"}]}}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, responseMessage, response.Type)
+	event := <-bot.events
+	assert.Equal(t, &app.Command{
+		ChatID: "1160000000000000001",
+		From:   &app.User{ID: "80351110224678912", Name: "kostage"},
+		Name:   "wvote",
+		Args:   "291 yes=0.7,abstain=0.3",
+	}, event.Command)
+
+	status, response = interact(t, server.URL, key, `{"type": 3, "application_id": "app", "token": "interaction-token",
+		"user": {"id": "80351110224678912", "username": "kostage"},
+		"message": {"id": "1170000000000000001", "channel_id": "1160000000000000001"},
+		"data": {"custom_id": "v y kaiyo-1 291"}}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, responseDeferredUpdate, response.Type)
+	event = <-bot.events
+	assert.Equal(t, &app.ButtonPress{
+		ID:      "app/interaction-token",
+		From:    &app.User{ID: "80351110224678912", Name: "kostage"},
+		Message: app.MessageRef{ChatID: "1160000000000000001", MessageID: "1170000000000000001"},
+		Data:    "v y kaiyo-1 291",
+	}, event.Press)
+
+	// signed by another key
+	_, otherKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	status, _ = interact(t, server.URL, otherKey, `{"type": 1}`)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Empty(t, bot.events)
+}
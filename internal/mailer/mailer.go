@@ -0,0 +1,113 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	dialTimeout = time.Second * 15
+	// whole conversation with the server
+	sendTimeout = time.Minute
+)
+
+// SMTPMailer sends html mails through an smtp server, STARTTLS is used when
+// the server offers it and credentials are sent only over tls or to localhost
+type SMTPMailer struct {
+	addr     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+// NewSMTPMailer sends to host:port as from, authenticating if username is set
+func NewSMTPMailer(addr string, username string, password string, from string, to []string) *SMTPMailer {
+	return &SMTPMailer{
+		addr:     addr,
+		username: username,
+		password: password,
+		from:     from,
+		to:       to,
+	}
+}
+
+func (m *SMTPMailer) Send(subject string, html string) error {
+	msg, err := m.message(subject, html, time.Now())
+	if err != nil {
+		return err
+	}
+	host, _, err := net.SplitHostPort(m.addr)
+	if err != nil {
+		return errors.Wrapf(err, "invalid smtp address '%s'", m.addr)
+	}
+	conn, err := net.DialTimeout("tcp", m.addr, dialTimeout)
+	if err != nil {
+		return errors.Wrapf(err, "failed to connect smtp server %s", m.addr)
+	}
+	conn.SetDeadline(time.Now().Add(sendTimeout))
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return errors.Wrapf(err, "failed to greet smtp server %s", m.addr)
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return errors.Wrap(err, "failed to start tls")
+		}
+	}
+	if m.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, host)); err != nil {
+			return errors.Wrap(err, "smtp authentication failed")
+		}
+	}
+	if err := client.Mail(m.from); err != nil {
+		return errors.Wrapf(err, "smtp server rejected sender %s", m.from)
+	}
+	for _, to := range m.to {
+		if err := client.Rcpt(to); err != nil {
+			return errors.Wrapf(err, "smtp server rejected recipient %s", to)
+		}
+	}
+	data, err := client.Data()
+	if err != nil {
+		return errors.Wrap(err, "smtp server rejected data")
+	}
+	if _, err := data.Write(msg); err != nil {
+		return errors.Wrap(err, "failed to write mail")
+	}
+	if err := data.Close(); err != nil {
+		return errors.Wrap(err, "smtp server rejected mail")
+	}
+	return client.Quit()
+}
+
+// message builds quoted-printable html mail
+func (m *SMTPMailer) message(subject string, html string, date time.Time) ([]byte, error) {
+	msg := bytes.Buffer{}
+	fmt.Fprintf(&msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(m.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	body := quotedprintable.NewWriter(&msg)
+	if _, err := body.Write([]byte(html)); err != nil {
+		return nil, errors.Wrap(err, "failed to encode mail")
+	}
+	if err := body.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to encode mail")
+	}
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,137 @@
+package mailer
+
+import (
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// received is a mail accepted by smtpServer
+type received struct {
+	auth string
+	from string
+	to   []string
+	data []byte
+}
+
+// smtpServer is an smtp stand-in accepting AUTH PLAIN of user:secret and
+// rejecting recipients at invalid.example
+type smtpServer struct {
+	listener net.Listener
+	mails    chan received
+}
+
+func startSMTPServer(t *testing.T) *smtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &smtpServer{listener: listener, mails: make(chan received, 10)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return server
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	text := textproto.NewConn(conn)
+	defer text.Close()
+	mail := received{}
+	text.PrintfLine("220 localhost ESMTP stand-in")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			text.PrintfLine("250-localhost\r\n250-AUTH PLAIN\r\n250 8BITMIME")
+		case "AUTH":
+			credentials, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, "PLAIN "))
+			if string(credentials) != "\x00user\x00secret" {
+				text.PrintfLine("535 authentication failed")
+				continue
+			}
+			mail.auth = "user"
+			text.PrintfLine("235 authenticated")
+		case "MAIL":
+			// parameters like BODY=8BITMIME follow the address
+			from, _, _ := strings.Cut(arg, " ")
+			mail.from = strings.Trim(strings.TrimPrefix(from, "FROM:"), "<>")
+			text.PrintfLine("250 ok")
+		case "RCPT":
+			to := strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			if strings.HasSuffix(to, "@invalid.example") {
+				text.PrintfLine("550 no such user")
+				continue
+			}
+			mail.to = append(mail.to, to)
+			text.PrintfLine("250 ok")
+		case "DATA":
+			text.PrintfLine("354 go ahead")
+			mail.data, err = text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mails <- mail
+			text.PrintfLine("250 queued")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	server := startSMTPServer(t)
+	mailer := NewSMTPMailer(server.listener.Addr().String(), "user", "secret",
+		"voter@example.com", []string{"ops@example.com", "validator@example.com"})
+
+	html := "<p>Kujira proposal 291 – " + strings.Repeat("long line ", 20) + "</p>"
+	require.NoError(t, mailer.Send("New Kujira proposal 291: GHOST – v2", html))
+	got := <-server.mails
+	assert.Equal(t, "user", got.auth)
+	assert.Equal(t, "voter@example.com", got.from)
+	assert.Equal(t, []string{"ops@example.com", "validator@example.com"}, got.to)
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(got.data)))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "New Kujira proposal 291: GHOST – v2", subject)
+	assert.Equal(t, "ops@example.com, validator@example.com", msg.Header.Get("To"))
+	assert.Equal(t, "text/html; charset=UTF-8", msg.Header.Get("Content-Type"))
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	require.NoError(t, err)
+	// dot reader ends data with the line break before '.'
+	assert.Equal(t, html, strings.TrimSuffix(string(body), "\n"))
+}
+
+func TestSendRejected(t *testing.T) {
+	server := startSMTPServer(t)
+	addr := server.listener.Addr().String()
+
+	err := NewSMTPMailer(addr, "user", "wrong", "voter@example.com", []string{"ops@example.com"}).Send("subject", "<p></p>")
+	assert.ErrorContains(t, err, "smtp authentication failed")
+
+	err = NewSMTPMailer(addr, "", "", "voter@example.com", []string{"ops@invalid.example"}).Send("subject", "<p></p>")
+	assert.ErrorContains(t, err, "rejected recipient ops@invalid.example")
+	assert.Empty(t, server.mails)
+}
@@ -0,0 +1,327 @@
+package matrixbot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/app"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	clientAPIPath = "/_matrix/client/v3"
+	// long poll of sync
+	syncTimeout = time.Second * 30
+	// pause after a failed sync
+	syncRetryDelay = time.Second * 5
+	maxBodySize    = 4 << 20
+
+	// matrix clients treat '/' commands themselves
+	commandPrefix = "!"
+)
+
+// MatrixBot is the matrix messenger of the app, matrix has no buttons so each
+// button is a reaction the bot adds to the prompt, reacting with it presses
+// the button, commands start with '!'
+type MatrixBot struct {
+	homeserverURL string
+	accessToken   string
+	// own events are skipped
+	userID string
+	client *http.Client
+	// transaction ids are unique per access token
+	txnPrefix string
+	txnSeq    atomic.Int64
+}
+
+func NewMatrixBot(homeserverURL string, accessToken string, userID string) *MatrixBot {
+	return &MatrixBot{
+		homeserverURL: strings.TrimSuffix(homeserverURL, "/"),
+		accessToken:   accessToken,
+		userID:        userID,
+		client:        &http.Client{Timeout: syncTimeout + time.Second*15},
+		txnPrefix:     fmt.Sprintf("cv%d", time.Now().UnixNano()),
+	}
+}
+
+type button struct {
+	Key  string `json:"key"`
+	Data string `json:"data"`
+}
+
+type relation struct {
+	RelType string `json:"rel_type"`
+	EventID string `json:"event_id"`
+	Key     string `json:"key,omitempty"`
+}
+
+type messageContent struct {
+	MsgType string `json:"msgtype"`
+	Body    string `json:"body"`
+	// signed data of buttons is kept in the prompt event, so that reactions
+	// to prompts sent before restart still work
+	Buttons    []button        `json:"cosmos_voter.buttons,omitempty"`
+	NewContent *messageContent `json:"m.new_content,omitempty"`
+	RelatesTo  *relation       `json:"m.relates_to,omitempty"`
+}
+
+type event struct {
+	Type    string          `json:"type"`
+	EventID string          `json:"event_id"`
+	Sender  string          `json:"sender"`
+	Content json.RawMessage `json:"content"`
+}
+
+type syncResponse struct {
+	NextBatch string `json:"next_batch"`
+	Rooms     struct {
+		Join map[string]struct {
+			Timeline struct {
+				Events []event `json:"events"`
+			} `json:"timeline"`
+		} `json:"join"`
+	} `json:"rooms"`
+}
+
+// body lists reactions to press as plain clients show no buttons
+func body(text string, buttons []app.Button) string {
+	if len(buttons) == 0 {
+		return text
+	}
+	keys := make([]string, 0, len(buttons))
+	for _, b := range buttons {
+		keys = append(keys, b.Text)
+	}
+	return fmt.Sprintf("%s\nReact with: %s", text, strings.Join(keys, ", "))
+}
+
+func contentButtons(buttons []app.Button) []button {
+	result := make([]button, 0, len(buttons))
+	for _, b := range buttons {
+		result = append(result, button{Key: b.Text, Data: b.Data})
+	}
+	return result
+}
+
+func (b *MatrixBot) Send(chatID string, text string, buttons []app.Button) (app.MessageRef, error) {
+	eventID, err := b.sendEvent(chatID, "m.room.message", messageContent{
+		MsgType: "m.text",
+		Body:    body(text, buttons),
+		Buttons: contentButtons(buttons),
+	})
+	if err != nil {
+		return app.MessageRef{}, errors.Wrap(err, "failed to send matrix message")
+	}
+	for _, button := range buttons {
+		_, err := b.sendEvent(chatID, "m.reaction", map[string]relation{
+			"m.relates_to": {RelType: "m.annotation", EventID: eventID, Key: button.Text},
+		})
+		if err != nil {
+			return app.MessageRef{}, errors.Wrapf(err, "failed to add '%s' reaction", button.Text)
+		}
+	}
+	return app.MessageRef{ChatID: chatID, MessageID: eventID}, nil
+}
+
+// Edit replaces the message text, reactions of removed buttons stay but do
+// nothing as the app rejects used buttons
+func (b *MatrixBot) Edit(ref app.MessageRef, text string, buttons []app.Button) error {
+	newContent := messageContent{MsgType: "m.text", Body: body(text, buttons), Buttons: contentButtons(buttons)}
+	_, err := b.sendEvent(ref.ChatID, "m.room.message", messageContent{
+		MsgType:    "m.text",
+		Body:       "* " + newContent.Body,
+		NewContent: &newContent,
+		RelatesTo:  &relation{RelType: "m.replace", EventID: ref.MessageID},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to edit matrix message")
+	}
+	return nil
+}
+
+// Answer posts a notice to the room as matrix has no private answers
+func (b *MatrixBot) Answer(press *app.ButtonPress, text string) error {
+	if text == "" {
+		return nil
+	}
+	name := ""
+	if press.From != nil {
+		name = press.From.Name + ": "
+	}
+	_, err := b.sendEvent(press.Message.ChatID, "m.room.message", messageContent{MsgType: "m.notice", Body: name + text})
+	if err != nil {
+		return errors.Wrap(err, "failed to answer matrix reaction")
+	}
+	return nil
+}
+
+func (b *MatrixBot) sendEvent(roomID string, eventType string, content interface{}) (string, error) {
+	txnID := fmt.Sprintf("%s-%d", b.txnPrefix, b.txnSeq.Add(1))
+	path := fmt.Sprintf("/rooms/%s/send/%s/%s", url.PathEscape(roomID), eventType, txnID)
+	sent := struct {
+		EventID string `json:"event_id"`
+	}{}
+	if err := b.call(context.Background(), http.MethodPut, path, content, &sent); err != nil {
+		return "", err
+	}
+	return sent.EventID, nil
+}
+
+func (b *MatrixBot) call(ctx context.Context, method string, path string, request interface{}, response interface{}) error {
+	var reqBody io.Reader
+	if request != nil {
+		encoded, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, b.homeserverURL+clientAPIPath+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+b.accessToken)
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("%s %s returned status %s: %s", method, path, resp.Status, string(respBody))
+	}
+	if err := json.Unmarshal(respBody, response); err != nil {
+		return errors.Wrapf(err, "failed to decode %s %s response", method, path)
+	}
+	return nil
+}
+
+// Receive long polls sync, events before the start are skipped
+func (b *MatrixBot) Receive(ctx context.Context, handler func(app.Event) error) error {
+	since := ""
+	for {
+		resp, err := b.sync(ctx, since)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			log.Errorf("matrix sync failed, retry in %s: %v", syncRetryDelay, err)
+			select {
+			case <-time.After(syncRetryDelay):
+				continue
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		if since != "" {
+			if err := b.handleSync(ctx, resp, handler); err != nil {
+				return err
+			}
+		}
+		since = resp.NextBatch
+	}
+}
+
+func (b *MatrixBot) sync(ctx context.Context, since string) (*syncResponse, error) {
+	query := url.Values{}
+	if since != "" {
+		query.Set("since", since)
+		query.Set("timeout", fmt.Sprint(syncTimeout.Milliseconds()))
+	}
+	resp := &syncResponse{}
+	if err := b.call(ctx, http.MethodGet, "/sync?"+query.Encode(), nil, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (b *MatrixBot) handleSync(ctx context.Context, resp *syncResponse, handler func(app.Event) error) error {
+	for roomID, room := range resp.Rooms.Join {
+		for _, ev := range room.Timeline.Events {
+			if ev.Sender == b.userID {
+				continue
+			}
+			appEvent, ok, err := b.convert(ctx, roomID, ev)
+			if err != nil {
+				log.Errorf("failed to convert matrix event %s: %v", ev.EventID, err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+			if err := handler(appEvent); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// convert turns '!' messages into commands and reactions to prompts into
+// button presses, other events are ignored
+func (b *MatrixBot) convert(ctx context.Context, roomID string, ev event) (app.Event, bool, error) {
+	from := &app.User{ID: ev.Sender, Name: ev.Sender}
+	switch ev.Type {
+	case "m.room.message":
+		content := messageContent{}
+		if err := json.Unmarshal(ev.Content, &content); err != nil {
+			return app.Event{}, false, err
+		}
+		if content.RelatesTo != nil || !strings.HasPrefix(content.Body, commandPrefix) {
+			return app.Event{}, false, nil
+		}
+		name, args, _ := strings.Cut(strings.TrimPrefix(content.Body, commandPrefix), " ")
+		return app.Event{Command: &app.Command{
+			ChatID: roomID,
+			From:   from,
+			Name:   name,
+			Args:   strings.TrimSpace(args),
+		}}, true, nil
+	case "m.reaction":
+		content := struct {
+			RelatesTo relation `json:"m.relates_to"`
+		}{}
+		if err := json.Unmarshal(ev.Content, &content); err != nil {
+			return app.Event{}, false, err
+		}
+		if content.RelatesTo.RelType != "m.annotation" {
+			return app.Event{}, false, nil
+		}
+		prompt := event{}
+		path := fmt.Sprintf("/rooms/%s/event/%s", url.PathEscape(roomID), url.PathEscape(content.RelatesTo.EventID))
+		if err := b.call(ctx, http.MethodGet, path, nil, &prompt); err != nil {
+			return app.Event{}, false, err
+		}
+		promptContent := messageContent{}
+		if err := json.Unmarshal(prompt.Content, &promptContent); err != nil {
+			return app.Event{}, false, err
+		}
+		for _, button := range promptContent.Buttons {
+			if button.Key != content.RelatesTo.Key {
+				continue
+			}
+			return app.Event{Press: &app.ButtonPress{
+				ID:      ev.EventID,
+				From:    from,
+				Message: app.MessageRef{ChatID: roomID, MessageID: content.RelatesTo.EventID},
+				Data:    button.Data,
+			}}, true, nil
+		}
+	}
+	return app.Event{}, false, nil
+}
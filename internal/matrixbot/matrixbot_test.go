@@ -0,0 +1,152 @@
+package matrixbot
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kostage/cosmos_voter/internal/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRoom   = "!ops:example.org"
+	testPrompt = "$prompt"
+)
+
+// homeserver is a stand-in recording sent events and serving sync batches
+type homeserver struct {
+	t       *testing.T
+	mtx     sync.Mutex
+	sent    []sentEvent
+	batches []string
+	prompt  string
+}
+
+type sentEvent struct {
+	path    string
+	content map[string]interface{}
+}
+
+func (h *homeserver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	assert.Equal(h.t, "Bearer token", r.Header.Get("Authorization"))
+	path := strings.TrimPrefix(r.URL.Path, clientAPIPath)
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	switch {
+	case r.Method == http.MethodPut && strings.HasPrefix(path, "/rooms/"+testRoom+"/send/"):
+		body, _ := io.ReadAll(r.Body)
+		content := map[string]interface{}{}
+		require.NoError(h.t, json.Unmarshal(body, &content))
+		h.sent = append(h.sent, sentEvent{path: path, content: content})
+		if strings.Contains(path, "/m.room.message/") && h.prompt == "" {
+			h.prompt = string(body)
+			w.Write([]byte(`{"event_id": "` + testPrompt + `"}`))
+			return
+		}
+		w.Write([]byte(`{"event_id": "$other"}`))
+	case r.Method == http.MethodGet && path == "/rooms/"+testRoom+"/event/"+testPrompt:
+		w.Write([]byte(`{"type": "m.room.message", "event_id": "` + testPrompt + `", "content": ` + h.prompt + `}`))
+	case r.Method == http.MethodGet && path == "/sync":
+		if len(h.batches) == 0 {
+			// long poll of the client ends with its context
+			h.mtx.Unlock()
+			<-r.Context().Done()
+			h.mtx.Lock()
+			return
+		}
+		w.Write([]byte(h.batches[0]))
+		h.batches = h.batches[1:]
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestSendAndEdit(t *testing.T) {
+	hs := &homeserver{t: t}
+	server := httptest.NewServer(hs)
+	defer server.Close()
+	bot := NewMatrixBot(server.URL+"/", "token", "@voter:example.org")
+
+	ref, err := bot.Send(testRoom, "Kujira proposal 291", []app.Button{
+		{Text: "Yes", Data: "v y kaiyo-1 291"},
+		{Text: "No", Data: "v n kaiyo-1 291"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, app.MessageRef{ChatID: testRoom, MessageID: testPrompt}, ref)
+	require.Len(t, hs.sent, 3)
+	assert.Equal(t, "Kujira proposal 291\nReact with: Yes, No", hs.sent[0].content["body"])
+	// the bot reacts with every button for users to click
+	for i, key := range []string{"Yes", "No"} {
+		assert.Contains(t, hs.sent[i+1].path, "/send/m.reaction/")
+		relation := hs.sent[i+1].content["m.relates_to"].(map[string]interface{})
+		assert.Equal(t, "m.annotation", relation["rel_type"])
+		assert.Equal(t, testPrompt, relation["event_id"])
+		assert.Equal(t, key, relation["key"])
+	}
+
+	require.NoError(t, bot.Edit(ref, "You voted yes", nil))
+	edit := hs.sent[3].content
+	assert.Equal(t, "You voted yes", edit["m.new_content"].(map[string]interface{})["body"])
+	assert.Equal(t, "m.replace", edit["m.relates_to"].(map[string]interface{})["rel_type"])
+
+	require.NoError(t, bot.Answer(&app.ButtonPress{
+		From:    &app.User{ID: "@alice:example.org", Name: "@alice:example.org"},
+		Message: ref,
+	}, "You are not an approver"))
+	assert.Equal(t, "m.notice", hs.sent[4].content["msgtype"])
+	assert.Equal(t, "@alice:example.org: You are not an approver", hs.sent[4].content["body"])
+}
+
+func TestReceive(t *testing.T) {
+	hs := &homeserver{t: t}
+	server := httptest.NewServer(hs)
+	defer server.Close()
+	bot := NewMatrixBot(server.URL, "token", "@voter:example.org")
+	_, err := bot.Send(testRoom, "Kujira proposal 291", []app.Button{{Text: "Yes", Data: "v y kaiyo-1 291"}})
+	require.NoError(t, err)
+
+	hs.mtx.Lock()
+	hs.batches = []string{
+		// initial sync history is skipped
+		`{"next_batch": "s1", "rooms": {"join": {"` + testRoom + `": {"timeline": {"events": [
+			{"type": "m.room.message", "event_id": "$old", "sender": "@alice:example.org", "content": {"msgtype": "m.text", "body": "!start"}}
+		]}}}}}`,
+		`{"next_batch": "s2", "rooms": {"join": {"` + testRoom + `": {"timeline": {"events": [
+			{"type": "m.reaction", "event_id": "$own", "sender": "@voter:example.org",
+				"content": {"m.relates_to": {"rel_type": "m.annotation", "event_id": "` + testPrompt + `", "key": "Yes"}}},
+			{"type": "m.room.message", "event_id": "$chat", "sender": "@alice:example.org", "content": {"msgtype": "m.text", "body": "hi"}},
+			{"type": "m.room.message", "event_id": "$cmd", "sender": "@alice:example.org",
+				"content": {"msgtype": "m.text", "body": "!wvote 291 yes=0.7,abstain=0.3"}},
+			{"type": "m.reaction", "event_id": "$press", "sender": "@alice:example.org",
+				"content": {"m.relates_to": {"rel_type": "m.annotation", "event_id": "` + testPrompt + `", "key": "Yes"}}}
+		]}}}}}`,
+	}
+	hs.mtx.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := []app.Event{}
+	err = bot.Receive(ctx, func(event app.Event) error {
+		events = append(events, event)
+		if len(events) == 2 {
+			cancel()
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	require.Len(t, events, 2)
+	alice := &app.User{ID: "@alice:example.org", Name: "@alice:example.org"}
+	assert.Equal(t, &app.Command{ChatID: testRoom, From: alice, Name: "wvote", Args: "291 yes=0.7,abstain=0.3"}, events[0].Command)
+	assert.Equal(t, &app.ButtonPress{
+		ID:      "$press",
+		From:    alice,
+		Message: app.MessageRef{ChatID: testRoom, MessageID: testPrompt},
+		Data:    "v y kaiyo-1 291",
+	}, events[1].Press)
+}
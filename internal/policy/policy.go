@@ -0,0 +1,254 @@
+package policy
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ActionAsk leaves the decision to a human
+	ActionAsk = "ask"
+)
+
+var (
+	coinRegexp = regexp.MustCompile(`^([0-9]+)([a-zA-Z][a-zA-Z0-9/:._-]*)$`)
+)
+
+// Policy decides how to vote on a proposal, rules are checked in order
+// and the first matching one wins
+type Policy struct {
+	Rules []Rule `yaml:"rules"`
+	// action when no rule matches, ask if empty
+	Default string `yaml:"default"`
+
+	defaultOption vote.VoteOption
+}
+
+type Rule struct {
+	Name string `yaml:"name"`
+	// chain ids the rule applies to, all chains if empty
+	Chains []string `yaml:"chains"`
+	Match  Match    `yaml:"match"`
+	// yes, no, abstain, no_with_veto or ask
+	Action string `yaml:"action"`
+
+	option vote.VoteOption
+}
+
+// Match conditions are all required, empty ones are skipped
+type Match struct {
+	// every message @type or legacy content @type must be listed
+	Types []string `yaml:"types"`
+	// regexp of proposal title
+	Title string `yaml:"title"`
+	// proposer must be listed, proposals without proposer never match
+	Proposers []string `yaml:"proposers"`
+	// admin of every message must be listed, the admin is set by the
+	// proposer so it does not tell who submitted the proposal
+	Admins []string `yaml:"admins"`
+	// code id of every message must be listed
+	CodeIDs []string `yaml:"code_ids"`
+	// deposit bounds like 10000000000ukuji
+	MinDeposit string `yaml:"min_deposit"`
+	MaxDeposit string `yaml:"max_deposit"`
+
+	title      *regexp.Regexp
+	minDeposit *coin
+	maxDeposit *coin
+}
+
+// Decision is the policy verdict on a proposal
+type Decision struct {
+	// VoteOptionUnspecified asks a human
+	Option vote.VoteOption
+	// name of the matched rule, empty if default action is taken
+	Rule string
+}
+
+func (d Decision) Ask() bool {
+	return d.Option == vote.VoteOptionUnspecified
+}
+
+// By names what made the decision for reports
+func (d Decision) By() string {
+	if d.Rule == "" {
+		return "default policy action"
+	}
+	return fmt.Sprintf("policy rule '%s'", d.Rule)
+}
+
+type coin struct {
+	amount *big.Int
+	denom  string
+}
+
+func LoadPolicy(path string) (*Policy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.Errorf("failed to read policy file %s due to %v", path, err)
+		return nil, errors.Wrapf(err, "failed to read policy file %s", path)
+	}
+	policy, err := ParsePolicy(content)
+	if err != nil {
+		log.Errorf("failed to parse policy file %s due to %v", path, err)
+		return nil, errors.Wrapf(err, "failed to parse policy file %s", path)
+	}
+	return policy, nil
+}
+
+func ParsePolicy(content []byte) (*Policy, error) {
+	policy := &Policy{}
+	if err := yaml.Unmarshal(content, policy); err != nil {
+		return nil, err
+	}
+	var err error
+	if policy.defaultOption, err = parseAction(policy.Default); err != nil {
+		return nil, errors.Wrap(err, "invalid default action")
+	}
+	for i := range policy.Rules {
+		rule := &policy.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("#%d", i+1)
+		}
+		if err := rule.compile(); err != nil {
+			return nil, errors.Wrapf(err, "invalid rule %s", rule.Name)
+		}
+	}
+	return policy, nil
+}
+
+func (r *Rule) compile() error {
+	var err error
+	if r.option, err = parseAction(r.Action); err != nil {
+		return err
+	}
+	if r.Match.Title != "" {
+		if r.Match.title, err = regexp.Compile(r.Match.Title); err != nil {
+			return errors.Wrap(err, "invalid title regexp")
+		}
+	}
+	if r.Match.MinDeposit != "" {
+		if r.Match.minDeposit, err = parseCoin(r.Match.MinDeposit); err != nil {
+			return errors.Wrap(err, "invalid min deposit")
+		}
+	}
+	if r.Match.MaxDeposit != "" {
+		if r.Match.maxDeposit, err = parseCoin(r.Match.MaxDeposit); err != nil {
+			return errors.Wrap(err, "invalid max deposit")
+		}
+	}
+	return nil
+}
+
+// Decide returns the action of the first rule matching proposal of chain
+func (p *Policy) Decide(chainID string, prop vote.Proposal) Decision {
+	for _, rule := range p.Rules {
+		if rule.appliesTo(chainID) && rule.Match.matches(prop) {
+			return Decision{Option: rule.option, Rule: rule.Name}
+		}
+	}
+	return Decision{Option: p.defaultOption}
+}
+
+func (r *Rule) appliesTo(chainID string) bool {
+	return len(r.Chains) == 0 || contains(r.Chains, chainID)
+}
+
+func (m *Match) matches(prop vote.Proposal) bool {
+	if len(m.Types) > 0 {
+		if !everyMessage(prop, func(msg vote.ProposalMessage) bool {
+			return contains(m.Types, msg.Type) || contains(m.Types, msg.ContentType)
+		}) {
+			return false
+		}
+	}
+	if m.title != nil && !m.title.MatchString(prop.Title) {
+		return false
+	}
+	if len(m.Proposers) > 0 && (prop.Proposer == "" || !contains(m.Proposers, prop.Proposer)) {
+		return false
+	}
+	if len(m.Admins) > 0 {
+		if !everyMessage(prop, func(msg vote.ProposalMessage) bool {
+			return contains(m.Admins, msg.Admin)
+		}) {
+			return false
+		}
+	}
+	if len(m.CodeIDs) > 0 {
+		if !everyMessage(prop, func(msg vote.ProposalMessage) bool {
+			return contains(m.CodeIDs, msg.CodeID)
+		}) {
+			return false
+		}
+	}
+	if m.minDeposit != nil && depositOf(prop, m.minDeposit.denom).Cmp(m.minDeposit.amount) < 0 {
+		return false
+	}
+	if m.maxDeposit != nil && depositOf(prop, m.maxDeposit.denom).Cmp(m.maxDeposit.amount) > 0 {
+		return false
+	}
+	return true
+}
+
+// everyMessage is false for proposals without messages, so that
+// message conditions never match text proposals
+func everyMessage(prop vote.Proposal, match func(vote.ProposalMessage) bool) bool {
+	if len(prop.Messages) == 0 {
+		return false
+	}
+	for _, msg := range prop.Messages {
+		if !match(msg) {
+			return false
+		}
+	}
+	return true
+}
+
+func depositOf(prop vote.Proposal, denom string) *big.Int {
+	total := new(big.Int)
+	for _, deposit := range prop.Deposit {
+		if deposit.Denom != denom {
+			continue
+		}
+		amount, ok := new(big.Int).SetString(deposit.Amount, 10)
+		if !ok {
+			log.Errorf("deposit amount '%s' of proposal %s is not integer", deposit.Amount, prop.Id)
+			continue
+		}
+		total.Add(total, amount)
+	}
+	return total
+}
+
+func parseAction(action string) (vote.VoteOption, error) {
+	if action == "" || action == ActionAsk {
+		return vote.VoteOptionUnspecified, nil
+	}
+	return vote.ParseVoteOption(action)
+}
+
+func parseCoin(s string) (*coin, error) {
+	parts := coinRegexp.FindStringSubmatch(s)
+	if parts == nil {
+		return nil, fmt.Errorf("'%s' is not a coin like 100ukuji", s)
+	}
+	amount, _ := new(big.Int).SetString(parts[1], 10)
+	return &coin{amount: amount, denom: parts[2]}, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
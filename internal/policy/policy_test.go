@@ -0,0 +1,117 @@
+package policy
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kostage/cosmos_voter/internal/vote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ghostProposal() vote.Proposal {
+	return vote.Proposal{
+		Id:    "291",
+		Title: "GHOST: Instantiate ATOM Vault",
+		// the admin proposes its own contracts
+		Proposer: "kujira1tsekaqv9vmem0zwskmf90gpf0twl6k57e8vdnq",
+		Messages: []vote.ProposalMessage{{
+			Type:        "/cosmos.gov.v1.MsgExecLegacyContent",
+			ContentType: "/cosmwasm.wasm.v1.InstantiateContractProposal",
+			Authority:   "kujira10d07y265gmmuvt4z0w9aw880jnsr700jt23ame",
+			Admin:       "kujira1tsekaqv9vmem0zwskmf90gpf0twl6k57e8vdnq",
+			CodeID:      "106",
+		}},
+		Deposit: []vote.Coin{{Denom: "ukuji", Amount: "10000000000"}},
+	}
+}
+
+func TestExamplePolicy(t *testing.T) {
+	content, err := os.ReadFile("../../policy_example.yaml")
+	require.NoError(t, err)
+	policy, err := ParsePolicy(content)
+	require.NoError(t, err)
+
+	decision := policy.Decide("kaiyo-1", ghostProposal())
+	assert.Equal(t, Decision{Option: vote.VoteOptionYes, Rule: "ghost vaults"}, decision)
+	assert.False(t, decision.Ask())
+	assert.True(t, policy.Decide("cosmoshub-4", ghostProposal()).Ask())
+}
+
+func TestPolicyMatch(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		match   string
+		mutate  func(*vote.Proposal)
+		matches bool
+	}{
+		{"message type", `types: [/cosmos.gov.v1.MsgExecLegacyContent]`, nil, true},
+		{"content type", `types: [/cosmwasm.wasm.v1.InstantiateContractProposal]`, nil, true},
+		{"every message type", `types: [/cosmwasm.wasm.v1.InstantiateContractProposal]`, func(p *vote.Proposal) {
+			p.Messages = append(p.Messages, vote.ProposalMessage{Type: "/cosmos.upgrade.v1beta1.MsgSoftwareUpgrade"})
+		}, false},
+		{"no messages", `types: [/cosmwasm.wasm.v1.InstantiateContractProposal]`, func(p *vote.Proposal) {
+			p.Messages = nil
+		}, false},
+		{"title", `title: "^GHOST: Instantiate"`, nil, true},
+		{"title mismatch", `title: "^Upgrade"`, nil, false},
+		{"admin", `admins: [kujira1tsekaqv9vmem0zwskmf90gpf0twl6k57e8vdnq]`, nil, true},
+		{"admin mismatch", `admins: [kujira1unknown]`, nil, false},
+		{"proposer", `proposers: [kujira1tsekaqv9vmem0zwskmf90gpf0twl6k57e8vdnq]`, nil, true},
+		{"unknown proposer", `proposers: [kujira1unknown]`, nil, false},
+		{"no proposer", `proposers: [""]`, func(p *vote.Proposal) { p.Proposer = "" }, false},
+		// anyone may propose a contract with a trusted admin or the gov authority
+		{"spoofed admin", `proposers: [kujira1tsekaqv9vmem0zwskmf90gpf0twl6k57e8vdnq]`, func(p *vote.Proposal) {
+			p.Proposer = "kujira1attacker"
+		}, false},
+		{"spoofed authority", `proposers: [kujira10d07y265gmmuvt4z0w9aw880jnsr700jt23ame]`, func(p *vote.Proposal) {
+			p.Proposer = "kujira1attacker"
+		}, false},
+		{"code id", `code_ids: ["105", "106"]`, nil, true},
+		{"code id mismatch", `code_ids: ["105"]`, nil, false},
+		{"min deposit", `min_deposit: 10000000000ukuji`, nil, true},
+		{"min deposit not reached", `min_deposit: 10000000001ukuji`, nil, false},
+		{"min deposit other denom", `min_deposit: 1uatom`, nil, false},
+		{"max deposit", `max_deposit: 10000000000ukuji`, nil, true},
+		{"max deposit exceeded", `max_deposit: 9999999999ukuji`, nil, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			policy, err := ParsePolicy([]byte("rules:\n  - action: no\n    match: {" + tc.match + "}\n"))
+			require.NoError(t, err)
+			prop := ghostProposal()
+			if tc.mutate != nil {
+				tc.mutate(&prop)
+			}
+			assert.Equal(t, tc.matches, !policy.Decide("kaiyo-1", prop).Ask())
+		})
+	}
+}
+
+func TestPolicyFirstRuleWins(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`
+rules:
+  - name: ask about ghost
+    match: {title: "^GHOST"}
+    action: ask
+  - name: everything else
+    action: abstain
+default: no
+`))
+	require.NoError(t, err)
+	assert.Equal(t, Decision{Rule: "ask about ghost"}, policy.Decide("kaiyo-1", ghostProposal()))
+	prop := ghostProposal()
+	prop.Title = "Upgrade"
+	assert.Equal(t, Decision{Option: vote.VoteOptionAbstain, Rule: "everything else"}, policy.Decide("kaiyo-1", prop))
+}
+
+func TestParsePolicyInvalid(t *testing.T) {
+	for _, content := range []string{
+		`rules: [{action: maybe}]`,
+		`rules: [{action: yes, match: {title: "("}}]`,
+		`rules: [{action: yes, match: {min_deposit: "ukuji"}}]`,
+		`default: sure`,
+	} {
+		_, err := ParsePolicy([]byte(content))
+		assert.Error(t, err, content)
+	}
+}
@@ -0,0 +1,299 @@
+package slackbot
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/app"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultAPIURL = "https://slack.com/api"
+
+	// requests signed earlier are rejected as replays
+	maxRequestAge = time.Minute * 5
+	maxBodySize   = 1 << 20
+	// slack limit of section text
+	maxSectionText = 3000
+	// presses and commands waiting for the app
+	eventsQueueSize = 100
+)
+
+// SlackBot is the slack messenger of the app, messages are sent with the web
+// api and slash commands and Block Kit button presses are received by an http
+// server slack app request urls point to
+type SlackBot struct {
+	token         string
+	signingSecret string
+	listenAddr    string
+	apiURL        string
+	client        *http.Client
+	events        chan app.Event
+}
+
+func NewSlackBot(token string, signingSecret string, listenAddr string) *SlackBot {
+	return &SlackBot{
+		token:         token,
+		signingSecret: signingSecret,
+		listenAddr:    listenAddr,
+		apiURL:        defaultAPIURL,
+		client:        &http.Client{Timeout: time.Second * 15},
+		events:        make(chan app.Event, eventsQueueSize),
+	}
+}
+
+type block struct {
+	Type     string    `json:"type"`
+	Text     *text     `json:"text,omitempty"`
+	Elements []element `json:"elements,omitempty"`
+}
+
+type text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type element struct {
+	Type     string `json:"type"`
+	Text     text   `json:"text"`
+	ActionID string `json:"action_id"`
+	Value    string `json:"value"`
+}
+
+type messageRequest struct {
+	Channel string  `json:"channel"`
+	TS      string  `json:"ts,omitempty"`
+	Text    string  `json:"text"`
+	Blocks  []block `json:"blocks"`
+}
+
+type apiResponse struct {
+	OK      bool   `json:"ok"`
+	Error   string `json:"error"`
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+// interaction is the payload of a button press
+type interaction struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Container struct {
+		MessageTS string `json:"message_ts"`
+		ChannelID string `json:"channel_id"`
+	} `json:"container"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+	ResponseURL string `json:"response_url"`
+}
+
+// blocks always has the text section, so that editing without buttons removes them
+func blocks(msg string, buttons []app.Button) []block {
+	if len(msg) > maxSectionText {
+		msg = strings.ToValidUTF8(msg[:maxSectionText-3], "") + "..."
+	}
+	result := []block{{Type: "section", Text: &text{Type: "plain_text", Text: msg}}}
+	if len(buttons) == 0 {
+		return result
+	}
+	actions := block{Type: "actions"}
+	for i, button := range buttons {
+		actions.Elements = append(actions.Elements, element{
+			Type:     "button",
+			Text:     text{Type: "plain_text", Text: button.Text},
+			ActionID: fmt.Sprintf("button_%d", i),
+			Value:    button.Data,
+		})
+	}
+	return append(result, actions)
+}
+
+func (b *SlackBot) Send(chatID string, msg string, buttons []app.Button) (app.MessageRef, error) {
+	resp, err := b.call("chat.postMessage", messageRequest{Channel: chatID, Text: msg, Blocks: blocks(msg, buttons)})
+	if err != nil {
+		return app.MessageRef{}, errors.Wrap(err, "failed to send slack message")
+	}
+	return app.MessageRef{ChatID: resp.Channel, MessageID: resp.TS}, nil
+}
+
+func (b *SlackBot) Edit(ref app.MessageRef, msg string, buttons []app.Button) error {
+	_, err := b.call("chat.update", messageRequest{
+		Channel: ref.ChatID,
+		TS:      ref.MessageID,
+		Text:    msg,
+		Blocks:  blocks(msg, buttons),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to edit slack message")
+	}
+	return nil
+}
+
+// Answer posts text only the presser sees, presses are acknowledged on receipt
+func (b *SlackBot) Answer(press *app.ButtonPress, msg string) error {
+	if msg == "" {
+		return nil
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"response_type":    "ephemeral",
+		"replace_original": false,
+		"text":             msg,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.Post(press.ID, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to answer slack button press")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("slack answered button press with status %s", resp.Status)
+	}
+	return nil
+}
+
+func (b *SlackBot) call(method string, request interface{}) (*apiResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, b.apiURL+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+b.token)
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	result := &apiResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode %s response with status %s", method, resp.Status)
+	}
+	// slack reports errors with status 200
+	if !result.OK {
+		return nil, errors.Errorf("%s failed: %s", method, result.Error)
+	}
+	return result, nil
+}
+
+func (b *SlackBot) Receive(ctx context.Context, handler func(app.Event) error) error {
+	server := &http.Server{Addr: b.listenAddr, Handler: b}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+	defer server.Close()
+	for {
+		select {
+		case event := <-b.events:
+			if err := handler(event); err != nil {
+				return err
+			}
+		case err := <-serverErr:
+			return errors.Wrap(err, "slack requests server stopped")
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// ServeHTTP accepts slash commands and interactions, both are acknowledged
+// at once as slack waits for 3 seconds only
+func (b *SlackBot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if err := b.verify(r.Header, body); err != nil {
+		log.Errorf("rejected slack request: %v", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	event, ok := formEvent(form)
+	if !ok {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	select {
+	case b.events <- event:
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	}
+}
+
+// verify checks slack signature v0=hmac_sha256(signing secret, v0:<timestamp>:<body>)
+func (b *SlackBot) verify(header http.Header, body []byte) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.Errorf("invalid timestamp '%s'", timestamp)
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > maxRequestAge || age < -maxRequestAge {
+		return errors.Errorf("request timestamp is %s off", age)
+	}
+	mac := hmac.New(sha256.New, []byte(b.signingSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// formEvent converts slash commands and block actions, other requests are ignored
+func formEvent(form url.Values) (app.Event, bool) {
+	if command := form.Get("command"); command != "" {
+		return app.Event{Command: &app.Command{
+			ChatID: form.Get("channel_id"),
+			From:   &app.User{ID: form.Get("user_id"), Name: form.Get("user_name")},
+			Name:   strings.TrimPrefix(command, "/"),
+			Args:   form.Get("text"),
+		}}, true
+	}
+	payload := interaction{}
+	if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+		return app.Event{}, false
+	}
+	if payload.Type != "block_actions" || len(payload.Actions) == 0 {
+		return app.Event{}, false
+	}
+	return app.Event{Press: &app.ButtonPress{
+		ID:   payload.ResponseURL,
+		From: &app.User{ID: payload.User.ID, Name: payload.User.Username},
+		Message: app.MessageRef{
+			ChatID:    payload.Container.ChannelID,
+			MessageID: payload.Container.MessageTS,
+		},
+		Data: payload.Actions[0].Value,
+	}}, true
+}
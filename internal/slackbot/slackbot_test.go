@@ -0,0 +1,147 @@
+package slackbot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+)
+
+func sign(req *http.Request, body string, at time.Time) {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestSendAndEdit(t *testing.T) {
+	requests := map[string]messageRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer xoxb-token", r.Header.Get("Authorization"))
+		request := messageRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		requests[r.URL.Path] = request
+		if request.Channel == "C404" {
+			w.Write([]byte(`{"ok": false, "error": "channel_not_found"}`))
+			return
+		}
+		w.Write([]byte(`{"ok": true, "channel": "C024BE91L", "ts": "1700000000.000100"}`))
+	}))
+	defer server.Close()
+	bot := NewSlackBot("xoxb-token", testSecret, "")
+	bot.apiURL = server.URL
+
+	ref, err := bot.Send("C024BE91L", "Kujira proposal 291", []app.Button{{Text: "Yes", Data: "v y kaiyo-1 291"}})
+	require.NoError(t, err)
+	assert.Equal(t, app.MessageRef{ChatID: "C024BE91L", MessageID: "1700000000.000100"}, ref)
+	sent := requests["/chat.postMessage"]
+	require.Len(t, sent.Blocks, 2)
+	assert.Equal(t, "Kujira proposal 291", sent.Blocks[0].Text.Text)
+	assert.Equal(t, "actions", sent.Blocks[1].Type)
+	assert.Equal(t, "Yes", sent.Blocks[1].Elements[0].Text.Text)
+	assert.Equal(t, "v y kaiyo-1 291", sent.Blocks[1].Elements[0].Value)
+
+	require.NoError(t, bot.Edit(ref, "You voted yes", nil))
+	edited := requests["/chat.update"]
+	assert.Equal(t, "1700000000.000100", edited.TS)
+	// buttons are gone
+	assert.Len(t, edited.Blocks, 1)
+
+	_, err = bot.Send("C404", "text", nil)
+	assert.ErrorContains(t, err, "channel_not_found")
+}
+
+func TestReceivePress(t *testing.T) {
+	answered := ""
+	responseServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		answered = string(body)
+	}))
+	defer responseServer.Close()
+	bot := NewSlackBot("xoxb-token", testSecret, "")
+	server := httptest.NewServer(bot)
+	defer server.Close()
+
+	payload := `{"type": "block_actions", "user": {"id": "U0G9QF9C6", "username": "kostage"},
+		"container": {"message_ts": "1700000000.000100", "channel_id": "C024BE91L"},
+		"actions": [{"action_id": "button_0", "value": "v y kaiyo-1 291"}],
+		"response_url": "` + responseServer.URL + `"}`
+	body := url.Values{"payload": {payload}}.Encode()
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(body))
+	require.NoError(t, err)
+	sign(req, body, time.Now())
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	event := <-bot.events
+	require.NotNil(t, event.Press)
+	assert.Equal(t, &app.ButtonPress{
+		ID:      responseServer.URL,
+		From:    &app.User{ID: "U0G9QF9C6", Name: "kostage"},
+		Message: app.MessageRef{ChatID: "C024BE91L", MessageID: "1700000000.000100"},
+		Data:    "v y kaiyo-1 291",
+	}, event.Press)
+
+	require.NoError(t, bot.Answer(event.Press, "You are not an approver"))
+	assert.Contains(t, answered, `"response_type":"ephemeral"`)
+	assert.Contains(t, answered, "You are not an approver")
+}
+
+func TestReceiveCommand(t *testing.T) {
+	bot := NewSlackBot("xoxb-token", testSecret, "")
+	server := httptest.NewServer(bot)
+	defer server.Close()
+
+	body := url.Values{
+		"command":    {"/wvote"},
+		"text":       {"291 yes=0.7,abstain=0.3"},
+		"channel_id": {"C024BE91L"},
+		"user_id":    {"U0G9QF9C6"},
+		"user_name":  {"kostage"},
+	}.Encode()
+	post := func(at time.Time) int {
+		req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(body))
+		require.NoError(t, err)
+		sign(req, body, at)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusOK, post(time.Now()))
+	event := <-bot.events
+	assert.Equal(t, &app.Command{
+		ChatID: "C024BE91L",
+		From:   &app.User{ID: "U0G9QF9C6", Name: "kostage"},
+		Name:   "wvote",
+		Args:   "291 yes=0.7,abstain=0.3",
+	}, event.Command)
+
+	// replayed
+	assert.Equal(t, http.StatusUnauthorized, post(time.Now().Add(-time.Hour)))
+	// not signed
+	resp, err := http.Post(server.URL, "application/x-www-form-urlencoded", strings.NewReader(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Empty(t, bot.events)
+}
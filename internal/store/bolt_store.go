@@ -0,0 +1,162 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	openTimeout = time.Second * 5
+)
+
+var (
+	proposalsBucket = []byte("proposals")
+	promptsBucket   = []byte("prompts")
+	votesBucket     = []byte("votes")
+)
+
+// BoltStore keeps records in a single bbolt file, values are json encoded.
+// Proposals are keyed by '<chain id>/<proposal id>', prompts and votes by
+// '<chain id>/<proposal id>/<sequence>' to list them per proposal
+type BoltStore struct {
+	db *bolt.DB
+}
+
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open store file %s", path)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{proposalsBucket, promptsBucket, votesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "failed to create store buckets")
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) GetProposal(chainID string, id string) (ProposalRecord, error) {
+	rec := ProposalRecord{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(proposalsBucket).Get([]byte(proposalKey(chainID, id)))
+		if value == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(value, &rec)
+	})
+	return rec, err
+}
+
+func (s *BoltStore) ListProposals() ([]ProposalRecord, error) {
+	recs := []ProposalRecord{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(proposalsBucket).ForEach(func(_, value []byte) error {
+			rec := ProposalRecord{}
+			if err := json.Unmarshal(value, &rec); err != nil {
+				return err
+			}
+			recs = append(recs, rec)
+			return nil
+		})
+	})
+	return recs, err
+}
+
+func (s *BoltStore) UpdateProposal(chainID string, id string, update func(*ProposalRecord) error) error {
+	key := []byte(proposalKey(chainID, id))
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(proposalsBucket)
+		rec := ProposalRecord{ChainID: chainID, ID: id}
+		if value := bucket.Get(key); value != nil {
+			if err := json.Unmarshal(value, &rec); err != nil {
+				return errors.Wrapf(err, "failed to unmarshal proposal %s", id)
+			}
+		}
+		if err := update(&rec); err != nil {
+			return err
+		}
+		value, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, value)
+	})
+}
+
+func (s *BoltStore) AddPrompt(rec PromptRecord) error {
+	return s.add(promptsBucket, proposalKey(rec.ChainID, rec.ProposalID), rec)
+}
+
+func (s *BoltStore) ListPrompts(chainID string, proposalID string) ([]PromptRecord, error) {
+	recs := []PromptRecord{}
+	err := s.list(promptsBucket, proposalKey(chainID, proposalID), func(value []byte) error {
+		rec := PromptRecord{}
+		if err := json.Unmarshal(value, &rec); err != nil {
+			return err
+		}
+		recs = append(recs, rec)
+		return nil
+	})
+	return recs, err
+}
+
+func (s *BoltStore) AddVote(rec VoteRecord) error {
+	return s.add(votesBucket, proposalKey(rec.ChainID, rec.ProposalID), rec)
+}
+
+func (s *BoltStore) ListVotes(chainID string, proposalID string) ([]VoteRecord, error) {
+	recs := []VoteRecord{}
+	err := s.list(votesBucket, proposalKey(chainID, proposalID), func(value []byte) error {
+		rec := VoteRecord{}
+		if err := json.Unmarshal(value, &rec); err != nil {
+			return err
+		}
+		recs = append(recs, rec)
+		return nil
+	})
+	return recs, err
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *BoltStore) add(bucketName []byte, key string, rec interface{}) error {
+	value, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketName)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(fmt.Sprintf("%s/%020d", key, seq)), value)
+	})
+}
+
+func (s *BoltStore) list(bucketName []byte, key string, handle func([]byte) error) error {
+	prefix := []byte(key + "/")
+	return s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(bucketName).Cursor()
+		for key, value := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, value = cursor.Next() {
+			if err := handle(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"sort"
+	"sync"
+)
+
+// MemStore keeps records in memory only, suitable for tests and runs without a store file
+type MemStore struct {
+	mtx       sync.Mutex
+	proposals map[string]ProposalRecord
+	prompts   map[string][]PromptRecord
+	votes     map[string][]VoteRecord
+}
+
+func NewMemStore() *MemStore {
+	return &MemStore{
+		proposals: make(map[string]ProposalRecord),
+		prompts:   make(map[string][]PromptRecord),
+		votes:     make(map[string][]VoteRecord),
+	}
+}
+
+func (s *MemStore) GetProposal(chainID string, id string) (ProposalRecord, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	rec, ok := s.proposals[proposalKey(chainID, id)]
+	if !ok {
+		return ProposalRecord{}, ErrNotFound
+	}
+	return rec, nil
+}
+
+func (s *MemStore) ListProposals() ([]ProposalRecord, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	recs := make([]ProposalRecord, 0, len(s.proposals))
+	for _, rec := range s.proposals {
+		recs = append(recs, rec)
+	}
+	sort.Slice(recs, func(i, j int) bool {
+		return proposalKey(recs[i].ChainID, recs[i].ID) < proposalKey(recs[j].ChainID, recs[j].ID)
+	})
+	return recs, nil
+}
+
+func (s *MemStore) UpdateProposal(chainID string, id string, update func(*ProposalRecord) error) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	key := proposalKey(chainID, id)
+	rec, ok := s.proposals[key]
+	if !ok {
+		rec = ProposalRecord{ChainID: chainID, ID: id}
+	}
+	if err := update(&rec); err != nil {
+		return err
+	}
+	s.proposals[key] = rec
+	return nil
+}
+
+func (s *MemStore) AddPrompt(rec PromptRecord) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	key := proposalKey(rec.ChainID, rec.ProposalID)
+	s.prompts[key] = append(s.prompts[key], rec)
+	return nil
+}
+
+func (s *MemStore) ListPrompts(chainID string, proposalID string) ([]PromptRecord, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]PromptRecord{}, s.prompts[proposalKey(chainID, proposalID)]...), nil
+}
+
+func (s *MemStore) AddVote(rec VoteRecord) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	key := proposalKey(rec.ChainID, rec.ProposalID)
+	s.votes[key] = append(s.votes[key], rec)
+	return nil
+}
+
+func (s *MemStore) ListVotes(chainID string, proposalID string) ([]VoteRecord, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]VoteRecord{}, s.votes[proposalKey(chainID, proposalID)]...), nil
+}
+
+func (s *MemStore) Close() error {
+	return nil
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrNotFound = fmt.Errorf("not found")
+)
+
+// MessengerID is a chat or message id of any messenger, records stored
+// before other messengers were supported have numeric telegram ids
+type MessengerID string
+
+func (id *MessengerID) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		return json.Unmarshal(data, (*string)(id))
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	*id = MessengerID(number)
+	return nil
+}
+
+type ProposalRecord struct {
+	ChainID       string    `json:"chain_id"`
+	ID            string    `json:"id"`
+	Title         string    `json:"title"`
+	VotingEndTime time.Time `json:"voting_end_time"`
+	FirstSeen     time.Time `json:"first_seen"`
+	Announced     bool      `json:"announced"`
+	// detection was notified, prompts failing to send are retried without it
+	Detected bool `json:"detected"`
+	// number of deadline reminders already sent
+	Reminded int `json:"reminded"`
+	// fallback vote was cast as nobody voted before the deadline
+	FallbackVoted bool `json:"fallback_voted"`
+	// options chosen by approvers while waiting for enough of them to agree
+	Approvals map[string]string `json:"approvals,omitempty"`
+	// nonces of prompts whose buttons were used
+	UsedCallbacks []string `json:"used_callbacks,omitempty"`
+}
+
+type PromptRecord struct {
+	ChainID    string      `json:"chain_id"`
+	ProposalID string      `json:"proposal_id"`
+	ChatID     MessengerID `json:"chat_id"`
+	MessageID  MessengerID `json:"message_id"`
+	SentAt     time.Time   `json:"sent_at"`
+	// binds signed button data to the prompt
+	Nonce string `json:"nonce"`
+}
+
+type VoteRecord struct {
+	ChainID    string `json:"chain_id"`
+	ProposalID string `json:"proposal_id"`
+	// option as passed to the voter, e.g. yes or yes=0.7,abstain=0.3
+	Option     string      `json:"option"`
+	TxHash     string      `json:"tx_hash"`
+	ApprovedBy []string    `json:"approved_by"`
+	ChatID     MessengerID `json:"chat_id"`
+	MessageID  MessengerID `json:"message_id"`
+	Submitted  time.Time   `json:"submitted"`
+}
+
+// Store keeps records per chain, proposal ids are only unique within a chain
+type Store interface {
+	GetProposal(chainID string, id string) (ProposalRecord, error)
+	ListProposals() ([]ProposalRecord, error)
+	// UpdateProposal atomically applies update to the record, creating it if missing
+	UpdateProposal(chainID string, id string, update func(*ProposalRecord) error) error
+	AddPrompt(PromptRecord) error
+	ListPrompts(chainID string, proposalID string) ([]PromptRecord, error)
+	AddVote(VoteRecord) error
+	ListVotes(chainID string, proposalID string) ([]VoteRecord, error)
+	Close() error
+}
+
+func proposalKey(chainID string, proposalID string) string {
+	return chainID + "/" + proposalID
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testStore(t *testing.T, s Store) {
+	_, err := s.GetProposal("kaiyo-1", "291")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	endTime := time.Date(2023, 4, 23, 13, 23, 26, 0, time.UTC)
+	err = s.UpdateProposal("kaiyo-1", "291", func(rec *ProposalRecord) error {
+		rec.Title = "GHOST: Instantiate ATOM Vault"
+		rec.VotingEndTime = endTime
+		rec.Announced = true
+		return nil
+	})
+	require.NoError(t, err)
+	err = s.UpdateProposal("kaiyo-1", "291", func(rec *ProposalRecord) error {
+		rec.Reminded++
+		return nil
+	})
+	require.NoError(t, err)
+	rec, err := s.GetProposal("kaiyo-1", "291")
+	require.NoError(t, err)
+	assert.Equal(t, ProposalRecord{
+		ChainID:       "kaiyo-1",
+		ID:            "291",
+		Title:         "GHOST: Instantiate ATOM Vault",
+		VotingEndTime: endTime,
+		Announced:     true,
+		Reminded:      1,
+	}, rec)
+	require.NoError(t, s.UpdateProposal("kaiyo-1", "2910", func(*ProposalRecord) error { return nil }))
+	require.NoError(t, s.UpdateProposal("cosmoshub-4", "291", func(*ProposalRecord) error { return nil }))
+	recs, err := s.ListProposals()
+	require.NoError(t, err)
+	assert.Len(t, recs, 3)
+	rec, err = s.GetProposal("cosmoshub-4", "291")
+	require.NoError(t, err)
+	assert.False(t, rec.Announced)
+
+	require.NoError(t, s.AddPrompt(PromptRecord{ChainID: "kaiyo-1", ProposalID: "291", ChatID: "1", MessageID: "10"}))
+	require.NoError(t, s.AddPrompt(PromptRecord{ChainID: "kaiyo-1", ProposalID: "2910", ChatID: "1", MessageID: "11"}))
+	require.NoError(t, s.AddPrompt(PromptRecord{ChainID: "cosmoshub-4", ProposalID: "291", ChatID: "1", MessageID: "12"}))
+	require.NoError(t, s.AddPrompt(PromptRecord{ChainID: "kaiyo-1", ProposalID: "291", ChatID: "1", MessageID: "13"}))
+	prompts, err := s.ListPrompts("kaiyo-1", "291")
+	require.NoError(t, err)
+	assert.Equal(t, []PromptRecord{
+		{ChainID: "kaiyo-1", ProposalID: "291", ChatID: "1", MessageID: "10"},
+		{ChainID: "kaiyo-1", ProposalID: "291", ChatID: "1", MessageID: "13"},
+	}, prompts)
+
+	vote := VoteRecord{ChainID: "kaiyo-1", ProposalID: "291", Option: "yes", ApprovedBy: []string{"kostage"}}
+	require.NoError(t, s.AddVote(vote))
+	votes, err := s.ListVotes("kaiyo-1", "291")
+	require.NoError(t, err)
+	assert.Equal(t, []VoteRecord{vote}, votes)
+	votes, err = s.ListVotes("kaiyo-1", "29")
+	require.NoError(t, err)
+	assert.Empty(t, votes)
+}
+
+func TestMemStore(t *testing.T) {
+	testStore(t, NewMemStore())
+}
+
+func TestBoltStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	s, err := NewBoltStore(path)
+	require.NoError(t, err)
+	testStore(t, s)
+	require.NoError(t, s.Close())
+
+	// records survive reopening
+	s, err = NewBoltStore(path)
+	require.NoError(t, err)
+	defer s.Close()
+	votes, err := s.ListVotes("kaiyo-1", "291")
+	require.NoError(t, err)
+	assert.Len(t, votes, 1)
+}
+
+func TestLegacyTelegramIDs(t *testing.T) {
+	prompt := PromptRecord{}
+	require.NoError(t, json.Unmarshal([]byte(`{"chat_id": -1001234567890, "message_id": 42}`), &prompt))
+	assert.Equal(t, MessengerID("-1001234567890"), prompt.ChatID)
+	assert.Equal(t, MessengerID("42"), prompt.MessageID)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"chat_id": "C024BE91L", "message_id": "1700000000.000100"}`), &prompt))
+	assert.Equal(t, MessengerID("C024BE91L"), prompt.ChatID)
+	assert.Equal(t, MessengerID("1700000000.000100"), prompt.MessageID)
+}
@@ -3,11 +3,14 @@ package tgbot
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/kostage/cosmos_voter/internal/app"
 	"github.com/pkg/errors"
 )
 
+// TgBot is the telegram messenger of the app
 type TgBot struct {
 	*tgbotapi.BotAPI
 }
@@ -49,3 +52,101 @@ func (b *TgBot) ProcessUpdates(
 		}
 	}
 }
+
+func (b *TgBot) Receive(ctx context.Context, handler func(app.Event) error) error {
+	return b.ProcessUpdates(ctx, func(update tgbotapi.Update) error {
+		if event, ok := updateEvent(update); ok {
+			return handler(event)
+		}
+		return nil
+	})
+}
+
+// updateEvent converts commands and callback queries, other updates are ignored
+func updateEvent(update tgbotapi.Update) (app.Event, bool) {
+	if update.Message != nil && update.Message.IsCommand() {
+		return app.Event{Command: &app.Command{
+			ChatID: strconv.FormatInt(update.Message.Chat.ID, 10),
+			From:   user(update.Message.From),
+			Name:   update.Message.Command(),
+			Args:   update.Message.CommandArguments(),
+		}}, true
+	}
+	query := update.CallbackQuery
+	if query == nil || query.Message == nil {
+		return app.Event{}, false
+	}
+	return app.Event{Press: &app.ButtonPress{
+		ID:      query.ID,
+		From:    user(query.From),
+		Message: messageRef(query.Message),
+		Data:    query.Data,
+	}}, true
+}
+
+func user(from *tgbotapi.User) *app.User {
+	if from == nil {
+		return nil
+	}
+	return &app.User{ID: strconv.Itoa(from.ID), Name: from.String()}
+}
+
+func messageRef(msg *tgbotapi.Message) app.MessageRef {
+	return app.MessageRef{
+		ChatID:    strconv.FormatInt(msg.Chat.ID, 10),
+		MessageID: strconv.Itoa(msg.MessageID),
+	}
+}
+
+func keyboard(buttons []app.Button) *tgbotapi.InlineKeyboardMarkup {
+	if len(buttons) == 0 {
+		return nil
+	}
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(buttons))
+	for _, button := range buttons {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(button.Text, button.Data))
+	}
+	markup := tgbotapi.NewInlineKeyboardMarkup(row)
+	return &markup
+}
+
+func (b *TgBot) Send(chatID string, text string, buttons []app.Button) (app.MessageRef, error) {
+	id, err := strconv.ParseInt(chatID, 10, 64)
+	if err != nil {
+		return app.MessageRef{}, errors.Errorf("tg chat id '%s' is not integer", chatID)
+	}
+	msg := tgbotapi.NewMessage(id, text)
+	if markup := keyboard(buttons); markup != nil {
+		msg.ReplyMarkup = markup
+	}
+	sent, err := b.BotAPI.Send(msg)
+	if err != nil {
+		return app.MessageRef{}, errors.Wrap(err, "failed to send tg message")
+	}
+	return messageRef(&sent), nil
+}
+
+func (b *TgBot) Edit(ref app.MessageRef, text string, buttons []app.Button) error {
+	chatID, err := strconv.ParseInt(ref.ChatID, 10, 64)
+	if err != nil {
+		return errors.Errorf("tg chat id '%s' is not integer", ref.ChatID)
+	}
+	messageID, err := strconv.Atoi(ref.MessageID)
+	if err != nil {
+		return errors.Errorf("tg message id '%s' is not integer", ref.MessageID)
+	}
+	msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	// keyboard is removed if not passed
+	msg.ReplyMarkup = keyboard(buttons)
+	if _, err := b.BotAPI.Send(msg); err != nil {
+		return errors.Wrap(err, "failed to edit tg message")
+	}
+	return nil
+}
+
+func (b *TgBot) Answer(press *app.ButtonPress, text string) error {
+	if _, err := b.BotAPI.AnswerCallbackQuery(tgbotapi.NewCallback(press.ID, text)); err != nil {
+		return errors.Wrap(err, "failed to answer tg callback query")
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package tgbot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/kostage/cosmos_voter/internal/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// standIn routes bot api requests to a local server
+type standIn struct {
+	url *url.URL
+}
+
+func (s standIn) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme = s.url.Scheme
+	req.URL.Host = s.url.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func testBot(t *testing.T, handler func(method string, form url.Values) string) *TgBot {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		if method == "getMe" {
+			w.Write([]byte(`{"ok": true, "result": {"id": 1, "is_bot": true, "username": "cosmos_voter_bot"}}`))
+			return
+		}
+		w.Write([]byte(`{"ok": true, "result": ` + handler(method, r.Form) + `}`))
+	}))
+	t.Cleanup(server.Close)
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	api, err := tgbotapi.NewBotAPIWithClient("token", &http.Client{Transport: standIn{url: serverURL}})
+	require.NoError(t, err)
+	return &TgBot{BotAPI: api}
+}
+
+func TestSendAndEdit(t *testing.T) {
+	forms := map[string]url.Values{}
+	bot := testBot(t, func(method string, form url.Values) string {
+		forms[method] = form
+		if method == "answerCallbackQuery" {
+			return "true"
+		}
+		return `{"message_id": 42, "chat": {"id": -100123}}`
+	})
+
+	ref, err := bot.Send("-100123", "Kujira proposal 291", []app.Button{{Text: "Yes", Data: "v y kaiyo-1 291"}})
+	require.NoError(t, err)
+	assert.Equal(t, app.MessageRef{ChatID: "-100123", MessageID: "42"}, ref)
+	assert.Equal(t, "-100123", forms["sendMessage"].Get("chat_id"))
+	assert.Contains(t, forms["sendMessage"].Get("reply_markup"), `"callback_data":"v y kaiyo-1 291"`)
+
+	require.NoError(t, bot.Edit(ref, "You voted yes", nil))
+	assert.Equal(t, "42", forms["editMessageText"].Get("message_id"))
+	assert.Empty(t, forms["editMessageText"].Get("reply_markup"))
+
+	require.NoError(t, bot.Answer(&app.ButtonPress{ID: "4382bfdwdsb323b2d9"}, "Skipped"))
+	assert.Equal(t, "4382bfdwdsb323b2d9", forms["answerCallbackQuery"].Get("callback_query_id"))
+	assert.Equal(t, "Skipped", forms["answerCallbackQuery"].Get("text"))
+
+	_, err = bot.Send("@channel", "text", nil)
+	assert.Error(t, err)
+}
+
+func TestUpdateEvent(t *testing.T) {
+	from := &tgbotapi.User{ID: 7, UserName: "kostage"}
+	chat := &tgbotapi.Chat{ID: -100123}
+	event, ok := updateEvent(tgbotapi.Update{Message: &tgbotapi.Message{
+		From:     from,
+		Chat:     chat,
+		Text:     "/wvote 291 yes=0.7,abstain=0.3",
+		Entities: &[]tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 6}},
+	}})
+	require.True(t, ok)
+	assert.Equal(t, &app.Command{
+		ChatID: "-100123",
+		From:   &app.User{ID: "7", Name: "kostage"},
+		Name:   "wvote",
+		Args:   "291 yes=0.7,abstain=0.3",
+	}, event.Command)
+
+	event, ok = updateEvent(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      "4382bfdwdsb323b2d9",
+		From:    from,
+		Message: &tgbotapi.Message{MessageID: 42, Chat: chat},
+		Data:    "v y kaiyo-1 291",
+	}})
+	require.True(t, ok)
+	assert.Equal(t, &app.ButtonPress{
+		ID:      "4382bfdwdsb323b2d9",
+		From:    &app.User{ID: "7", Name: "kostage"},
+		Message: app.MessageRef{ChatID: "-100123", MessageID: "42"},
+		Data:    "v y kaiyo-1 291",
+	}, event.Press)
+
+	_, ok = updateEvent(tgbotapi.Update{Message: &tgbotapi.Message{From: from, Chat: chat, Text: "hi"}})
+	assert.False(t, ok)
+}
@@ -0,0 +1,181 @@
+package vote
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// inner messages of authz exec, built without signing
+	cosmosGenerateVoteCmdArgs         = "tx gov vote %s %s --from %s --chain-id %s --generate-only"
+	cosmosGenerateWeightedVoteCmdArgs = "tx gov weighted-vote %s %s --from %s --chain-id %s --generate-only"
+	cosmosAuthzExecCmdArgs            = "tx authz exec %s --from %s %s --chain-id %s"
+
+	// grants required to vote, 'tx gov vote' of sdk v0.46+ generates gov v1 messages
+	MsgVoteTypeURL         = "/cosmos.gov.v1.MsgVote"
+	MsgVoteWeightedTypeURL = "/cosmos.gov.v1.MsgVoteWeighted"
+)
+
+var (
+	voteMsgTypeURLs = []string{MsgVoteTypeURL, MsgVoteWeightedTypeURL}
+)
+
+// AuthzVoter votes on behalf of a granter account, so that the granter key
+// is not needed on the bot host
+type AuthzVoter interface {
+	// Granter returns the account votes are cast for, empty if authz is not used
+	Granter() string
+	// CheckGrant fails if voter wallet has no unexpired MsgVote or MsgVoteWeighted
+	// grant of granter, the grant expiring first is returned
+	CheckGrant(ctx context.Context) (*Grant, error)
+}
+
+// Grant is an authz authorization of grantee to send messages of granter
+type Grant struct {
+	Granter string
+	Grantee string
+	MsgType string
+	// nil if grant never expires
+	Expiration *time.Time
+}
+
+type cosmosGrantsResponse struct {
+	Grants     []cosmosGrant     `json:"grants"`
+	Pagination *cosmosPagination `json:"pagination"`
+}
+
+type cosmosGrant struct {
+	Authorization cosmosAuthorization `json:"authorization"`
+	Expiration    *time.Time          `json:"expiration"`
+}
+
+type cosmosAuthorization struct {
+	Type string `json:"@type"`
+	// msg type url of generic authorization
+	Msg string `json:"msg"`
+}
+
+// UseAuthz makes voter wallet vote as grantee of granter with 'tx authz exec',
+// gov queries then check votes of granter
+func (cv *CosmosVoter) UseAuthz(granter string) {
+	cv.granter = granter
+}
+
+func (cv *CosmosVoter) Granter() string {
+	return cv.granter
+}
+
+// voter returns the account votes are cast for
+func (cv *CosmosVoter) voter() string {
+	if cv.granter != "" {
+		return cv.granter
+	}
+	return cv.voterWallet
+}
+
+func (cv *CosmosVoter) CheckGrant(ctx context.Context) (*Grant, error) {
+	if cv.granter == "" {
+		return nil, fmt.Errorf("authz granter is not configured")
+	}
+	var first *Grant
+	problems := []string{}
+	for _, msgType := range voteMsgTypeURLs {
+		grant, err := cv.checkGrant(ctx, msgType)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+		if grant != nil && (first == nil || first.Expiration == nil ||
+			(grant.Expiration != nil && grant.Expiration.Before(*first.Expiration))) {
+			first = grant
+		}
+	}
+	if len(problems) > 0 {
+		return first, fmt.Errorf("%s", strings.Join(problems, ", "))
+	}
+	return first, nil
+}
+
+// checkGrant returns grant of msgType, expired grant is returned with error
+func (cv *CosmosVoter) checkGrant(ctx context.Context, msgType string) (*Grant, error) {
+	grants, err := cv.querier.grants(ctx, cv.granter, cv.voterWallet, msgType)
+	if err != nil {
+		return nil, err
+	}
+	if len(grants) == 0 {
+		return nil, fmt.Errorf("%s has no %s grant from %s", cv.voterWallet, msgType, cv.granter)
+	}
+	grant := &Grant{
+		Granter:    cv.granter,
+		Grantee:    cv.voterWallet,
+		MsgType:    msgType,
+		Expiration: grants[0].Expiration,
+	}
+	// several grants of the same type are not possible, take the longest anyway
+	for _, cosmosGrant := range grants[1:] {
+		if cosmosGrant.Expiration == nil ||
+			(grant.Expiration != nil && cosmosGrant.Expiration.After(*grant.Expiration)) {
+			grant.Expiration = cosmosGrant.Expiration
+		}
+	}
+	if grant.Expiration != nil && grant.Expiration.Before(time.Now()) {
+		return grant, fmt.Errorf("%s grant of %s to %s expired at %s",
+			msgType, cv.granter, cv.voterWallet, grant.Expiration.UTC().Format(time.RFC3339))
+	}
+	return grant, nil
+}
+
+// execAuthz generates unsigned vote tx of granter and sends it wrapped into
+// MsgExec signed by voter wallet
+func (cv *CosmosVoter) execAuthz(ctx context.Context, generateArgs []string, txName string) (*VoteResult, error) {
+	var result *VoteResult
+	err := cv.withGeneratedTx(ctx, generateArgs, txName, func(txFile string) error {
+		var err error
+		result, err = cv.send(ctx, cv.authzExecArgs(txFile), "authz exec "+txName)
+		return err
+	})
+	return result, err
+}
+
+func (cv *CosmosVoter) estimateAuthzFee(ctx context.Context, generateArgs []string) (*FeeEstimate, error) {
+	var estimate *FeeEstimate
+	err := cv.withGeneratedTx(ctx, generateArgs, "vote", func(txFile string) error {
+		var err error
+		estimate, err = cv.simulate(ctx, cv.authzExecArgs(txFile))
+		return err
+	})
+	return estimate, err
+}
+
+func (cv *CosmosVoter) authzExecArgs(txFile string) []string {
+	return strings.Fields(fmt.Sprintf(
+		cosmosAuthzExecCmdArgs, txFile, cv.voterWallet, cv.feeFlags(), cv.chainId))
+}
+
+// withGeneratedTx passes file with unsigned tx of generateArgs to use, the file
+// is removed afterwards
+func (cv *CosmosVoter) withGeneratedTx(
+	ctx context.Context, generateArgs []string, txName string, use func(txFile string) error,
+) error {
+	runner := defRunnerFactory()
+	generated, stderr, err := runner.Run(ctx, cv.daemonPath, generateArgs, nil)
+	if err != nil {
+		logCmdErr(cv.daemonPath, generateArgs, generated, stderr, err)
+		return fmt.Errorf("failed to generate %s tx: %v", txName, err)
+	}
+	txFile, err := os.CreateTemp("", "cosmos_voter_*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create %s tx file: %v", txName, err)
+	}
+	defer os.Remove(txFile.Name())
+	if _, err := txFile.Write(generated); err != nil {
+		txFile.Close()
+		return fmt.Errorf("failed to write %s tx file: %v", txName, err)
+	}
+	if err := txFile.Close(); err != nil {
+		return fmt.Errorf("failed to write %s tx file: %v", txName, err)
+	}
+	return use(txFile.Name())
+}
@@ -0,0 +1,144 @@
+package vote
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/kostage/cosmos_voter/internal/cmdrunner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "embed"
+)
+
+//go:embed example_authz_grants.json
+var example_authz_grants []byte
+
+//go:embed example_vote_tx_generated.json
+var example_vote_tx_generated []byte
+
+func TestCosmosAuthzVote(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	expectedGenerateArgs := []string{
+		"tx", "gov", "vote", "1", "yes", "--from", "granterWallet", "--chain-id", "kaiyo-1", "--generate-only",
+	}
+	txFile := ""
+	gomock.InOrder(
+		runner.EXPECT().Run(gomock.Any(), "daemon", expectedGenerateArgs, nil).Return(example_vote_tx_generated, nil, nil),
+		runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), []byte("password")).DoAndReturn(
+			func(_ context.Context, _ string, args []string, _ []byte) ([]byte, []byte, error) {
+				require.Len(t, args, 13)
+				txFile = args[3]
+				assert.Equal(t, []string{
+					"tx", "authz", "exec", txFile, "--from", "voterWallet",
+					"--fees", "250ukuji", "--chain-id", "kaiyo-1", "-y", "-o", "json",
+				}, args)
+				generated, err := os.ReadFile(txFile)
+				require.NoError(t, err)
+				assert.Equal(t, example_vote_tx_generated, generated)
+				return example_tx_broadcast, nil, nil
+			}),
+		expectTxIncluded(runner),
+	)
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "250ukuji", "kaiyo-1")
+	voter.UseAuthz("granterWallet")
+	result, err := voter.Vote(context.Background(), "1", VoteOptionYes)
+	assert.NoError(t, err)
+	assert.Equal(t, exampleTxHash, result.TxHash)
+	_, err = os.Stat(txFile)
+	assert.True(t, os.IsNotExist(err), "generated tx file is left behind")
+}
+
+func TestCosmosAuthzVoteGenerateFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), nil).Return(nil, nil, fmt.Errorf("unknown address"))
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "250ukuji", "kaiyo-1")
+	voter.UseAuthz("granterWallet")
+	options := WeightedVoteOptions{
+		{Option: VoteOptionYes, Weight: 0.7},
+		{Option: VoteOptionAbstain, Weight: 0.3},
+	}
+	_, err := voter.WeightedVote(context.Background(), "1", options)
+	assert.Error(t, err)
+}
+
+func TestCosmosAuthzHasVoted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	expectedArgs := []string{"query", "gov", "vote", "1", "granterWallet", "-o", "json"}
+	runner.EXPECT().Run(gomock.Any(), "daemon", expectedArgs, nil).Return(example_vote_294, nil, nil)
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "", "")
+	voter.UseAuthz("granterWallet")
+	voted, err := voter.HasVoted(context.Background(), "1")
+	assert.NoError(t, err)
+	assert.True(t, voted)
+}
+
+func TestCosmosCheckGrant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	expectedVoteArgs := []string{
+		"query", "authz", "grants", "granterWallet", "voterWallet", "/cosmos.gov.v1.MsgVote", "-o", "json",
+	}
+	expectedWeightedArgs := []string{
+		"query", "authz", "grants", "granterWallet", "voterWallet", "/cosmos.gov.v1.MsgVoteWeighted", "-o", "json",
+	}
+	weighted := []byte(`{"grants": [{"authorization": {"msg": "/cosmos.gov.v1.MsgVoteWeighted"}, "expiration": "2029-01-01T00:00:00Z"}]}`)
+	runner.EXPECT().Run(gomock.Any(), "daemon", expectedVoteArgs, nil).Return(example_authz_grants, nil, nil)
+	runner.EXPECT().Run(gomock.Any(), "daemon", expectedWeightedArgs, nil).Return(weighted, nil, nil)
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "", "")
+	_, err := voter.CheckGrant(context.Background())
+	assert.Error(t, err)
+
+	voter.UseAuthz("granterWallet")
+	grant, err := voter.CheckGrant(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "granterWallet", grant.Granter)
+	assert.Equal(t, "voterWallet", grant.Grantee)
+	// the grant expiring first is reported
+	assert.Equal(t, MsgVoteWeightedTypeURL, grant.MsgType)
+	require.NotNil(t, grant.Expiration)
+	assert.Equal(t, time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC), grant.Expiration.UTC())
+}
+
+func TestCosmosCheckGrantExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	expired := []byte(`{"grants": [{"authorization": {"msg": "/cosmos.gov.v1.MsgVote"}, "expiration": "2020-01-01T00:00:00Z"}]}`)
+	none := []byte(`{"grants": []}`)
+	gomock.InOrder(
+		runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), nil).Return(expired, nil, nil),
+		runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), nil).Return(example_authz_grants, nil, nil),
+		runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), nil).Return(example_authz_grants, nil, nil),
+		runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), nil).Return(none, nil, nil),
+		runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), nil).Return(none, nil, nil),
+		runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), nil).Return(none, nil, nil),
+	)
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "", "")
+	voter.UseAuthz("granterWallet")
+	_, err := voter.CheckGrant(context.Background())
+	assert.EqualError(t, err, "/cosmos.gov.v1.MsgVote grant of granterWallet to voterWallet expired at 2020-01-01T00:00:00Z")
+	// weighted votes need their own grant
+	_, err = voter.CheckGrant(context.Background())
+	assert.EqualError(t, err, "voterWallet has no /cosmos.gov.v1.MsgVoteWeighted grant from granterWallet")
+	// every missing grant is reported
+	_, err = voter.CheckGrant(context.Background())
+	assert.EqualError(t, err, "voterWallet has no /cosmos.gov.v1.MsgVote grant from granterWallet, "+
+		"voterWallet has no /cosmos.gov.v1.MsgVoteWeighted grant from granterWallet")
+}
@@ -0,0 +1,83 @@
+package vote
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	// sdk default gas limit, a vote takes about half of it
+	defaultVoteGas = 200000
+)
+
+// BalanceChecker tells whether voter wallet can pay for votes
+type BalanceChecker interface {
+	// Balance returns voter wallet balance in fee denom
+	Balance(ctx context.Context) (*Balance, error)
+}
+
+type Balance struct {
+	// wallet paying fees, voter wallet also pays for authz exec
+	Wallet string
+	Amount *big.Int
+	Denom  string
+	// fee of a vote derived from fee config, 0 if votes are free
+	VoteFee *big.Int
+}
+
+// Votes returns how many votes the balance pays for, -1 if votes are free
+func (b *Balance) Votes() int64 {
+	if b.VoteFee.Sign() == 0 {
+		return -1
+	}
+	return new(big.Int).Quo(b.Amount, b.VoteFee).Int64()
+}
+
+func (b *Balance) String() string {
+	if b.VoteFee.Sign() == 0 {
+		return fmt.Sprintf("%s%s", b.Amount, b.Denom)
+	}
+	return fmt.Sprintf("%s%s, enough for %d votes", b.Amount, b.Denom, b.Votes())
+}
+
+func (cv *CosmosVoter) Balance(ctx context.Context) (*Balance, error) {
+	fee, err := cv.voteFee()
+	if err != nil {
+		return nil, err
+	}
+	amount, err := cv.querier.balance(ctx, cv.voterWallet, fee.denom)
+	if err != nil {
+		return nil, err
+	}
+	balance := &Balance{
+		Wallet:  cv.voterWallet,
+		Amount:  new(big.Int),
+		Denom:   fee.denom,
+		VoteFee: ceilInt(fee.amount),
+	}
+	if _, ok := balance.Amount.SetString(amount, 10); !ok {
+		return nil, fmt.Errorf("balance '%s' of %s is not integer", amount, fee.denom)
+	}
+	return balance, nil
+}
+
+// voteFee returns the most a vote may cost according to fee config
+func (cv *CosmosVoter) voteFee() (*decCoin, error) {
+	if cv.gasPrice == nil {
+		// fees may list several coins, the first one is assumed to be paid
+		fee, err := parseDecCoin(strings.Split(cv.fees, ",")[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid fees: %v", err)
+		}
+		return fee, nil
+	}
+	if cv.maxFee != nil {
+		return cv.maxFee, nil
+	}
+	return &decCoin{
+		amount: new(big.Rat).SetInt(cv.gasFee(defaultVoteGas)),
+		denom:  cv.gasPrice.denom,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package vote
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/kostage/cosmos_voter/internal/cmdrunner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "embed"
+)
+
+//go:embed example_balances.json
+var example_balances []byte
+
+func TestCosmosBalance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	expectedArgs := []string{"query", "bank", "balances", "voterWallet", "-o", "json"}
+	runner.EXPECT().Run(gomock.Any(), "daemon", expectedArgs, nil).Return(example_balances, nil, nil).Times(3)
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "250ukuji", "kaiyo-1")
+	balance, err := voter.Balance(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(12480), balance.Amount)
+	assert.Equal(t, "ukuji", balance.Denom)
+	assert.Equal(t, int64(49), balance.Votes())
+	assert.Equal(t, "12480ukuji, enough for 49 votes", balance.String())
+
+	// 200000 default gas at 0.00125 costs 250
+	require.NoError(t, voter.UseGasPrices("0.00125ukuji", 0, ""))
+	balance, err = voter.Balance(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(49), balance.Votes())
+
+	require.NoError(t, voter.UseGasPrices("0.00125ukuji", 0, "1000ukuji"))
+	balance, err = voter.Balance(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), balance.Votes())
+}
+
+func TestCosmosBalanceNoCoins(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), nil).Return([]byte(`{"balances": []}`), nil, nil)
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "250uatom", "cosmoshub-4")
+	balance, err := voter.Balance(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "uatom", balance.Denom)
+	assert.Equal(t, int64(0), balance.Votes())
+}
+
+func TestCosmosBalanceInvalidFees(t *testing.T) {
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "", "kaiyo-1")
+	_, err := voter.Balance(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,208 @@
+package vote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	cosmosGetVotingCmdArgs   = "query gov proposals --status VotingPeriod --limit %d -o json"
+	cosmosHasVotedCmdArgs    = "query gov vote %s %s -o json"
+	cosmosTallyCmdArgs       = "query gov tally %s -o json"
+	cosmosStakingPoolCmdArgs = "query staking pool -o json"
+	cosmosGovParamsCmdArgs   = "query gov params -o json"
+	cosmosAuthzGrantsCmdArgs = "query authz grants %s %s %s -o json"
+	cosmosBalancesCmdArgs    = "query bank balances %s -o json"
+)
+
+// cliQuerier runs queries with the daemon binary
+type cliQuerier struct {
+	daemonPath string
+}
+
+// proposals pages with --page as the cli takes --page-key as raw bytes
+// while next_key of the response is base64, page keys are page numbers here
+func (q *cliQuerier) proposals(ctx context.Context) ([]cosmosProposal, error) {
+	return queryAllPages(func(pageKey string) ([]cosmosProposal, string, error) {
+		page := 1
+		if pageKey != "" {
+			var err error
+			if page, err = strconv.Atoi(pageKey); err != nil {
+				return nil, "", fmt.Errorf("invalid page '%s': %v", pageKey, err)
+			}
+		}
+		proposals, nextKey, err := q.proposalsPage(ctx, page)
+		if err != nil || nextKey == "" {
+			return proposals, "", err
+		}
+		return proposals, strconv.Itoa(page + 1), nil
+	})
+}
+
+func (q *cliQuerier) proposalsPage(ctx context.Context, page int) ([]cosmosProposal, string, error) {
+	args := strings.Fields(fmt.Sprintf(cosmosGetVotingCmdArgs, pageLimit))
+	if page > 1 {
+		args = append(args, "--page", strconv.Itoa(page))
+	}
+	runner := defRunnerFactory()
+	stdout, stderr, err := runner.Run(
+		ctx,
+		q.daemonPath,
+		args,
+		nil,
+	)
+	if err != nil {
+		logCmdErr(q.daemonPath, args, stdout, stderr, err)
+		return nil, "", fmt.Errorf("failed to run cosmos proposals query: %v", err)
+	}
+	cosmosProposals := cosmosProposalsResponse{}
+	if err := json.Unmarshal(stdout, &cosmosProposals); err != nil {
+		logCmdErr(q.daemonPath, args, stdout, stderr, err)
+		return nil, "", fmt.Errorf("failed to unmarshal cosmos proposals: %v", err)
+	}
+	return cosmosProposals.Proposals, cosmosProposals.Pagination.nextKey(), nil
+}
+
+func (q *cliQuerier) vote(ctx context.Context, id string, voter string) (*cosmosHasVotedResponse, error) {
+	args := strings.Fields(fmt.Sprintf(cosmosHasVotedCmdArgs, id, voter))
+	runner := defRunnerFactory()
+	stdout, stderr, err := runner.Run(
+		ctx,
+		q.daemonPath,
+		args,
+		nil,
+	)
+	if err != nil {
+		// query fails if there is no vote, any other failure must not pass for not voted
+		if strings.Contains(string(stderr), "not found") {
+			return nil, nil
+		}
+		logCmdErr(q.daemonPath, args, stdout, stderr, err)
+		return nil, fmt.Errorf("failed to run vote query: %v", err)
+	}
+	hasVoted := &cosmosHasVotedResponse{}
+	if err := json.Unmarshal(stdout, hasVoted); err != nil {
+		logCmdErr(q.daemonPath, args, stdout, stderr, err)
+		return nil, fmt.Errorf("failed to unmarshal voted query response: %v", err)
+	}
+	return hasVoted, nil
+}
+
+func (q *cliQuerier) tally(ctx context.Context, id string) (*cosmosTallyResponse, error) {
+	args := strings.Fields(fmt.Sprintf(cosmosTallyCmdArgs, id))
+	runner := defRunnerFactory()
+	stdout, stderr, err := runner.Run(
+		ctx,
+		q.daemonPath,
+		args,
+		nil,
+	)
+	if err != nil {
+		logCmdErr(q.daemonPath, args, stdout, stderr, err)
+		return nil, fmt.Errorf("failed to run tally query: %v", err)
+	}
+	tally := &cosmosTallyResponse{}
+	if err := json.Unmarshal(stdout, tally); err != nil {
+		logCmdErr(q.daemonPath, args, stdout, stderr, err)
+		return nil, fmt.Errorf("failed to unmarshal tally query response: %v", err)
+	}
+	return tally, nil
+}
+
+func (q *cliQuerier) bondedTokens(ctx context.Context) (float64, error) {
+	args := strings.Fields(cosmosStakingPoolCmdArgs)
+	runner := defRunnerFactory()
+	stdout, stderr, err := runner.Run(
+		ctx,
+		q.daemonPath,
+		args,
+		nil,
+	)
+	if err != nil {
+		logCmdErr(q.daemonPath, args, stdout, stderr, err)
+		return 0, fmt.Errorf("failed to run staking pool query: %v", err)
+	}
+	pool := &cosmosStakingPoolResponse{}
+	if err := json.Unmarshal(stdout, pool); err != nil {
+		logCmdErr(q.daemonPath, args, stdout, stderr, err)
+		return 0, fmt.Errorf("failed to unmarshal staking pool response: %v", err)
+	}
+	return pool.bondedTokens()
+}
+
+func (q *cliQuerier) tallyParams(ctx context.Context) (*cosmosTallyParams, error) {
+	args := strings.Fields(cosmosGovParamsCmdArgs)
+	runner := defRunnerFactory()
+	stdout, stderr, err := runner.Run(
+		ctx,
+		q.daemonPath,
+		args,
+		nil,
+	)
+	if err != nil {
+		logCmdErr(q.daemonPath, args, stdout, stderr, err)
+		return nil, fmt.Errorf("failed to run gov params query: %v", err)
+	}
+	params := &cosmosGovParamsResponse{}
+	if err := json.Unmarshal(stdout, params); err != nil {
+		logCmdErr(q.daemonPath, args, stdout, stderr, err)
+		return nil, fmt.Errorf("failed to unmarshal gov params response: %v", err)
+	}
+	return params.tallyParams()
+}
+
+func (q *cliQuerier) grants(ctx context.Context, granter string, grantee string, msgType string) ([]cosmosGrant, error) {
+	args := strings.Fields(fmt.Sprintf(cosmosAuthzGrantsCmdArgs, granter, grantee, msgType))
+	runner := defRunnerFactory()
+	stdout, stderr, err := runner.Run(
+		ctx,
+		q.daemonPath,
+		args,
+		nil,
+	)
+	if err != nil {
+		// query fails if there is no grant as well
+		logCmdErr(q.daemonPath, args, stdout, stderr, err)
+		return nil, fmt.Errorf("failed to run authz grants query: %v", err)
+	}
+	grants := cosmosGrantsResponse{}
+	if err := json.Unmarshal(stdout, &grants); err != nil {
+		logCmdErr(q.daemonPath, args, stdout, stderr, err)
+		return nil, fmt.Errorf("failed to unmarshal authz grants: %v", err)
+	}
+	return grants.Grants, nil
+}
+
+type cosmosBalancesResponse struct {
+	Balances []cosmosCoin `json:"balances"`
+}
+
+func (q *cliQuerier) balance(ctx context.Context, address string, denom string) (string, error) {
+	args := strings.Fields(fmt.Sprintf(cosmosBalancesCmdArgs, address))
+	runner := defRunnerFactory()
+	stdout, stderr, err := runner.Run(
+		ctx,
+		q.daemonPath,
+		args,
+		nil,
+	)
+	if err != nil {
+		logCmdErr(q.daemonPath, args, stdout, stderr, err)
+		return "", fmt.Errorf("failed to run cosmos balances query: %v", err)
+	}
+	balances := cosmosBalancesResponse{}
+	if err := json.Unmarshal(stdout, &balances); err != nil {
+		logCmdErr(q.daemonPath, args, stdout, stderr, err)
+		return "", fmt.Errorf("failed to unmarshal cosmos balances: %v", err)
+	}
+	// wallet without coins of denom has no entry
+	for _, coin := range balances.Balances {
+		if coin.Denom == denom {
+			return coin.Amount, nil
+		}
+	}
+	return "0", nil
+}
@@ -2,7 +2,6 @@ package vote
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"math"
 	"strconv"
@@ -11,36 +10,82 @@ import (
 
 	"github.com/kostage/cosmos_voter/internal/cmdrunner"
 	log "github.com/sirupsen/logrus"
-	"gopkg.in/yaml.v3"
 )
 
 var (
-	cosmosGetVotingCmdArgs  = "query gov proposals --status VotingPeriod -o json"
-	cosmosHasVotedCmdArgs   = "query gov vote %s %s -o json"
-	cosmosTallyCmdArgs      = "query gov tally %s -o json"
-	cosmosVoteCmdArgs       = "tx gov vote %s %s --from %s --fees %s --chain-id %s -y"
-	cosmosValidatorsCmdArgs = "query tendermint-validator-set"
+	// fee flags are substituted before chain id
+	cosmosVoteCmdArgs         = "tx gov vote %s %s --from %s %s --chain-id %s"
+	cosmosWeightedVoteCmdArgs = "tx gov weighted-vote %s %s --from %s %s --chain-id %s"
 
 	defRunnerFactory = cmdrunner.NewCmdRunner
 )
 
 type cosmosProposalsResponse struct {
-	Proposals []cosmosProposal `json:"proposals"`
+	Proposals  []cosmosProposal  `json:"proposals"`
+	Pagination *cosmosPagination `json:"pagination"`
 }
 
 type cosmosProposal struct {
 	ProposalID    string                  `json:"id"`
 	Messages      []cosmosProposalMessage `json:"messages"`
 	VotingEndTime time.Time               `json:"voting_end_time"`
+	TotalDeposit  []cosmosCoin            `json:"total_deposit"`
+	Proposer      string                  `json:"proposer"`
 }
 
 type cosmosProposalMessage struct {
-	Content cosmosProposalContent `json:"content"`
+	Type      string                `json:"@type"`
+	Content   cosmosProposalContent `json:"content"`
+	Authority string                `json:"authority"`
+	Admin     string                `json:"admin"`
+	CodeID    string                `json:"code_id"`
 }
 
 type cosmosProposalContent struct {
+	Type        string `json:"@type"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
+	Admin       string `json:"admin"`
+	CodeID      string `json:"code_id"`
+}
+
+type cosmosCoin struct {
+	Denom  string `json:"denom"`
+	Amount string `json:"amount"`
+}
+
+// messages returns typed proposal messages, admin and code id are taken from
+// legacy content if message has none
+func (p *cosmosProposal) messages() []ProposalMessage {
+	messages := make([]ProposalMessage, 0, len(p.Messages))
+	for _, msg := range p.Messages {
+		if msg.Type == "" {
+			continue
+		}
+		message := ProposalMessage{
+			Type:        msg.Type,
+			ContentType: msg.Content.Type,
+			Authority:   msg.Authority,
+			Admin:       msg.Admin,
+			CodeID:      msg.CodeID,
+		}
+		if message.Admin == "" {
+			message.Admin = msg.Content.Admin
+		}
+		if message.CodeID == "" {
+			message.CodeID = msg.Content.CodeID
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+func (p *cosmosProposal) deposit() []Coin {
+	deposit := make([]Coin, 0, len(p.TotalDeposit))
+	for _, coin := range p.TotalDeposit {
+		deposit = append(deposit, Coin{Denom: coin.Denom, Amount: coin.Amount})
+	}
+	return deposit
 }
 
 type cosmosHasVotedResponse struct {
@@ -52,31 +97,159 @@ type cosmosVotedOption struct {
 	Option string `json:"option"`
 }
 
+// cosmosTallyResponse counts are floats like bonded tokens as 18 decimal
+// chains overflow integers
 type cosmosTallyResponse struct {
-	Yes        int `json:"yes_count,string"`
-	Abstain    int `json:"abstain_count,string"`
-	No         int `json:"no_count,string"`
-	NoWithVeto int `json:"no_with_veto_count,string"`
+	Yes        float64 `json:"yes_count,string"`
+	Abstain    float64 `json:"abstain_count,string"`
+	No         float64 `json:"no_count,string"`
+	NoWithVeto float64 `json:"no_with_veto_count,string"`
 }
 
 type cosmosNumVotesResponse struct {
 	Votes []interface{} `json:"votes"`
 }
 
-type cosmosValidatorsResponse struct {
-	Validators []cosmosValidator `yaml:"validators"`
+type cosmosStakingPool struct {
+	NotBondedTokens string `json:"not_bonded_tokens"`
+	BondedTokens    string `json:"bonded_tokens"`
+}
+
+type cosmosStakingPoolResponse struct {
+	cosmosStakingPool
+	// LCD and sdk v0.50+ cli wrap the pool
+	Pool *cosmosStakingPool `json:"pool"`
+}
+
+func (r *cosmosStakingPoolResponse) bondedTokens() (float64, error) {
+	pool := &r.cosmosStakingPool
+	if r.Pool != nil {
+		pool = r.Pool
+	}
+	bonded, err := strconv.ParseFloat(pool.BondedTokens, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to unmarshal staking pool response: bonded tokens '%s' is not a number", pool.BondedTokens)
+	}
+	return bonded, nil
+}
+
+type cosmosTallyParams struct {
+	Quorum        string `json:"quorum"`
+	Threshold     string `json:"threshold"`
+	VetoThreshold string `json:"veto_threshold"`
+}
+
+type cosmosGovParamsResponse struct {
+	// deprecated since sdk v0.47 in favour of params, still filled by older nodes
+	TallyParams *cosmosTallyParams `json:"tally_params"`
+	Params      *cosmosTallyParams `json:"params"`
+}
+
+func (r *cosmosGovParamsResponse) tallyParams() (*cosmosTallyParams, error) {
+	if r.Params != nil && r.Params.Quorum != "" {
+		return r.Params, nil
+	}
+	if r.TallyParams != nil && r.TallyParams.Quorum != "" {
+		return r.TallyParams, nil
+	}
+	return nil, fmt.Errorf("gov params response has no tally params")
+}
+
+// tallyParams are gov tally params as fractions
+type tallyParams struct {
+	quorum        float64
+	threshold     float64
+	vetoThreshold float64
+}
+
+func (p *cosmosTallyParams) parse() (tallyParams, error) {
+	params := tallyParams{}
+	for _, param := range []struct {
+		value string
+		dest  *float64
+	}{
+		{p.Quorum, &params.quorum},
+		{p.Threshold, &params.threshold},
+		{p.VetoThreshold, &params.vetoThreshold},
+	} {
+		var err error
+		if *param.dest, err = strconv.ParseFloat(param.value, 64); err != nil {
+			return params, fmt.Errorf("tally param '%s' is not a number", param.value)
+		}
+	}
+	return params, nil
+}
+
+// wouldPass follows gov module tally: turnout of bonded tokens must reach quorum,
+// veto share of all votes must not exceed veto threshold and yes share of
+// non-abstain votes must exceed threshold
+func (p tallyParams) wouldPass(tally *cosmosTallyResponse, bonded float64) bool {
+	all := tally.Yes + tally.No + tally.NoWithVeto + tally.Abstain
+	if bonded <= 0 || all/bonded < p.quorum {
+		return false
+	}
+	nonAbstain := all - tally.Abstain
+	if nonAbstain == 0 {
+		return false
+	}
+	if tally.NoWithVeto/all > p.vetoThreshold {
+		return false
+	}
+	return tally.Yes/nonAbstain > p.threshold
 }
 
-type cosmosValidator struct {
-	VotingPower string `yaml:"voting_power"`
+// tally converts tally counts to percents
+func (p tallyParams) tally(tally *cosmosTallyResponse, bonded float64) Tally {
+	all := tally.Yes + tally.No + tally.NoWithVeto + tally.Abstain
+	return Tally{
+		VotedYes:      percent(tally.Yes, all),
+		VotedNo:       percent(tally.No, all),
+		Veto:          percent(tally.NoWithVeto, all),
+		Voted:         percent(all, bonded),
+		Quorum:        percent(p.quorum, 1),
+		Threshold:     percent(p.threshold, 1),
+		VetoThreshold: percent(p.vetoThreshold, 1),
+		WouldPass:     p.wouldPass(tally, bonded),
+	}
+}
+
+// percent returns part of whole in percents rounded to hundredths
+func percent(part float64, whole float64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(part*10000/whole) / 100
+}
+
+// govQuerier fetches gov and staking data for CosmosVoter
+type govQuerier interface {
+	proposals(ctx context.Context) ([]cosmosProposal, error)
+	// vote returns nil response if voter has not voted on proposal
+	vote(ctx context.Context, id string, voter string) (*cosmosHasVotedResponse, error)
+	tally(ctx context.Context, id string) (*cosmosTallyResponse, error)
+	// bondedTokens returns bonded tokens of staking pool, turnout is measured against them
+	bondedTokens(ctx context.Context) (float64, error)
+	tallyParams(ctx context.Context) (*cosmosTallyParams, error)
+	// grants returns authorizations of msgType from granter to grantee, empty if there are none
+	grants(ctx context.Context, granter string, grantee string, msgType string) ([]cosmosGrant, error)
+	// balance returns integer amount of denom held by address, 0 if there is none
+	balance(ctx context.Context, address string, denom string) (string, error)
 }
 
 type CosmosVoter struct {
+	querier      govQuerier
 	daemonPath   string
 	keychainPass string
 	voterWallet  string
 	fees         string
 	chainId      string
+	// votes are sent as authz exec on behalf of granter if set
+	granter string
+	// gas is simulated and paid at gas price instead of static fees if set
+	gasPrice      *decCoin
+	gasAdjustment float64
+	// txs with estimated fee above are refused, no limit if nil
+	maxFee *decCoin
 }
 
 func NewCosmosVoter(
@@ -87,6 +260,7 @@ func NewCosmosVoter(
 	chainId string,
 ) *CosmosVoter {
 	return &CosmosVoter{
+		querier:      &cliQuerier{daemonPath: daemonPath},
 		daemonPath:   daemonPath,
 		keychainPass: keychainPass,
 		voterWallet:  voterWallet,
@@ -95,30 +269,60 @@ func NewCosmosVoter(
 	}
 }
 
+// NewCosmosLcdVoter creates CosmosVoter which queries gov data from LCD REST
+// endpoint, transactions are still sent with the daemon binary
+func NewCosmosLcdVoter(
+	lcdURL string,
+	daemonPath string,
+	keychainPass string,
+	voterWallet string,
+	fees string,
+	chainId string,
+) *CosmosVoter {
+	cv := NewCosmosVoter(daemonPath, keychainPass, voterWallet, fees, chainId)
+	cv.querier = newLcdQuerier(lcdURL)
+	return cv
+}
+
+// NewCosmosGrpcVoter creates CosmosVoter which queries gov data from gRPC
+// endpoint of a node, transactions are still sent with the daemon binary
+func NewCosmosGrpcVoter(
+	grpcAddr string,
+	grpcTLS bool,
+	daemonPath string,
+	keychainPass string,
+	voterWallet string,
+	fees string,
+	chainId string,
+) (*CosmosVoter, error) {
+	querier, err := newGrpcQuerier(grpcAddr, grpcTLS)
+	if err != nil {
+		return nil, err
+	}
+	cv := NewCosmosVoter(daemonPath, keychainPass, voterWallet, fees, chainId)
+	cv.querier = querier
+	return cv, nil
+}
+
 func (cv *CosmosVoter) GetVoting(ctx context.Context) ([]Proposal, error) {
-	args := strings.Fields(cosmosGetVotingCmdArgs)
-	runner := defRunnerFactory()
-	stdout, stderr, err := runner.Run(
-		ctx,
-		cv.daemonPath,
-		args,
-		nil,
-	)
+	cosmosProposals, err := cv.querier.proposals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bonded, err := cv.querier.bondedTokens(ctx)
 	if err != nil {
-		logCmdErr(cv.daemonPath, args, stdout, stderr, err)
-		return nil, fmt.Errorf("failed to run cosmos proposals query: %v", err)
+		return nil, err
 	}
-	cosmosProposals := cosmosProposalsResponse{}
-	if err := json.Unmarshal(stdout, &cosmosProposals); err != nil {
-		logCmdErr(cv.daemonPath, args, stdout, stderr, err)
-		return nil, fmt.Errorf("failed to unmarshal cosmos proposals: %v", err)
+	cosmosParams, err := cv.querier.tallyParams(ctx)
+	if err != nil {
+		return nil, err
 	}
-	totalPower, err := cv.totalVotingPower(ctx)
+	params, err := cosmosParams.parse()
 	if err != nil {
 		return nil, err
 	}
-	proposals := make([]Proposal, 0, len(cosmosProposals.Proposals))
-	for _, cosmosProp := range cosmosProposals.Proposals {
+	proposals := make([]Proposal, 0, len(cosmosProposals))
+	for _, cosmosProp := range cosmosProposals {
 		if len(cosmosProp.Messages) == 0 {
 			return nil, fmt.Errorf("prop %s messages empty - no description", cosmosProp.ProposalID)
 		}
@@ -127,120 +331,105 @@ func (cv *CosmosVoter) GetVoting(ctx context.Context) ([]Proposal, error) {
 			log.Infof("skip already voted proposal %s", cosmosProp.ProposalID)
 			continue
 		}
-		tally, err := cv.tally(ctx, cosmosProp.ProposalID)
+		tally, err := cv.querier.tally(ctx, cosmosProp.ProposalID)
 		if err != nil {
 			return nil, err
 		}
-		all := float64(tally.Yes + tally.No + tally.NoWithVeto + tally.Abstain)
-		yes := float64(tally.Yes) * 100 / all
-		no := float64(tally.No) * 100 / all
-		veto := float64(tally.NoWithVeto) * 100 / all
+		t := params.tally(tally, bonded)
 		endsInHrs := cosmosProp.VotingEndTime.Sub(time.Now().UTC()).Hours()
 		endsInHrs = math.Round(endsInHrs*100) / 100
-		voted := float64(all) / float64(totalPower*10000)
-		voted = math.Round(voted*100) / 100
 		proposals = append(proposals, Proposal{
-			Id:          cosmosProp.ProposalID,
-			Title:       cosmosProp.Messages[0].Content.Title,
-			Description: cosmosProp.Messages[0].Content.Description,
-			VotedYes:    math.Round(yes*100) / 100,
-			VotedNo:     math.Round(no*100) / 100,
-			Veto:        math.Round(veto*100) / 100,
-			DeadlineHrs: endsInHrs,
-			Voted:       voted,
+			Id:            cosmosProp.ProposalID,
+			Title:         cosmosProp.Messages[0].Content.Title,
+			Description:   cosmosProp.Messages[0].Content.Description,
+			Proposer:      cosmosProp.Proposer,
+			Messages:      cosmosProp.messages(),
+			Deposit:       cosmosProp.deposit(),
+			VotedYes:      t.VotedYes,
+			VotedNo:       t.VotedNo,
+			Veto:          t.Veto,
+			DeadlineHrs:   endsInHrs,
+			Voted:         t.Voted,
+			Quorum:        t.Quorum,
+			Threshold:     t.Threshold,
+			VetoThreshold: t.VetoThreshold,
+			WouldPass:     t.WouldPass,
+
+			VotingEndTime: cosmosProp.VotingEndTime,
 		})
 	}
 	return proposals, nil
 }
 
-func (cv *CosmosVoter) HasVoted(ctx context.Context, id string) (bool, error) {
-	args := strings.Fields(fmt.Sprintf(cosmosHasVotedCmdArgs, id, cv.voterWallet))
-	runner := defRunnerFactory()
-	stdout, stderr, err := runner.Run(
-		ctx,
-		cv.daemonPath,
-		args,
-		nil,
-	)
+func (cv *CosmosVoter) Tally(ctx context.Context, id string) (*Tally, error) {
+	bonded, err := cv.querier.bondedTokens(ctx)
 	if err != nil {
-		return false, nil
+		return nil, err
 	}
-	hasVoted := cosmosHasVotedResponse{}
-	if err := json.Unmarshal(stdout, &hasVoted); err != nil {
-		logCmdErr(cv.daemonPath, args, stdout, stderr, err)
-		return false, fmt.Errorf("failed to unmarshal voted query response: %v", err)
+	cosmosParams, err := cv.querier.tallyParams(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return (len(hasVoted.Options) > 0 &&
-			hasVoted.Options[0].Option == "VOTE_OPTION_YES" ||
-			hasVoted.Options[0].Option == "VOTE_OPTION_NO"), nil
-}
-
-func (cv *CosmosVoter) Vote(ctx context.Context, id string, vote string) error {
-	args := strings.Fields(fmt.Sprintf(
-		cosmosVoteCmdArgs, id, vote, cv.voterWallet, cv.fees, cv.chainId))
-	runner := defRunnerFactory()
-	stdout, stderr, err := runner.Run(
-		ctx,
-		cv.daemonPath,
-		args,
-		[]byte(cv.keychainPass),
-	)
+	params, err := cosmosParams.parse()
 	if err != nil {
-		logCmdErr(cv.daemonPath, args, stdout, stderr, err)
-		return fmt.Errorf("failed to run vote tx: %v", err)
+		return nil, err
+	}
+	tally, err := cv.querier.tally(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	log.Infof("vote tx:\n%s", string(stdout))
-	return nil
+	t := params.tally(tally, bonded)
+	return &t, nil
 }
 
-func (cv *CosmosVoter) tally(ctx context.Context, id string) (*cosmosTallyResponse, error) {
-	args := strings.Fields(fmt.Sprintf(cosmosTallyCmdArgs, id))
-	runner := defRunnerFactory()
-	stdout, stderr, err := runner.Run(
-		ctx,
-		cv.daemonPath,
-		args,
-		nil,
-	)
+func (cv *CosmosVoter) HasVoted(ctx context.Context, id string) (bool, error) {
+	hasVoted, err := cv.querier.vote(ctx, id, cv.voter())
 	if err != nil {
-		logCmdErr(cv.daemonPath, args, stdout, stderr, err)
-		return nil, fmt.Errorf("failed to run tally query: %v", err)
+		return false, err
+	}
+	if hasVoted == nil {
+		return false, nil
 	}
-	tally := &cosmosTallyResponse{}
-	if err := json.Unmarshal(stdout, tally); err != nil {
-		logCmdErr(cv.daemonPath, args, stdout, stderr, err)
-		return nil, fmt.Errorf("failed to unmarshal tally query response: %v", err)
+	for _, voted := range hasVoted.Options {
+		if _, err := ParseVoteOption(voted.Option); err == nil {
+			return true, nil
+		}
 	}
-	return tally, nil
+	return false, nil
 }
 
-func (cv *CosmosVoter) totalVotingPower(ctx context.Context) (int, error) {
-	args := strings.Fields(fmt.Sprintf(cosmosValidatorsCmdArgs))
-	runner := defRunnerFactory()
-	stdout, stderr, err := runner.Run(
-		ctx,
-		cv.daemonPath,
-		args,
-		nil,
-	)
-	if err != nil {
-		logCmdErr(cv.daemonPath, args, stdout, stderr, err)
-		return 0, fmt.Errorf("failed to run tally query: %v", err)
+func (cv *CosmosVoter) Vote(ctx context.Context, id string, option VoteOption) (*VoteResult, error) {
+	if cv.granter != "" {
+		return cv.execAuthz(ctx, strings.Fields(fmt.Sprintf(
+			cosmosGenerateVoteCmdArgs, id, option, cv.granter, cv.chainId)), "vote")
+	}
+	return cv.send(ctx, cv.voteArgs(id, option), "vote")
+}
+
+func (cv *CosmosVoter) WeightedVote(ctx context.Context, id string, options WeightedVoteOptions) (*VoteResult, error) {
+	if err := options.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid weighted vote options: %v", err)
 	}
-	validators := &cosmosValidatorsResponse{}
-	if err := yaml.Unmarshal(stdout, validators); err != nil {
-		logCmdErr(cv.daemonPath, args, stdout, stderr, err)
-		return 0, fmt.Errorf("failed to unmarshal tendermint validators response: %v", err)
+	if cv.granter != "" {
+		return cv.execAuthz(ctx, strings.Fields(fmt.Sprintf(
+			cosmosGenerateWeightedVoteCmdArgs, id, options, cv.granter, cv.chainId)), "weighted vote")
 	}
-	totalPower := 0
-	for _, validator := range validators.Validators {
-		pow, err := strconv.Atoi(validator.VotingPower)
-		if err != nil {
-			return 0, fmt.Errorf("failed to unmarshal tendermint validators response: voting power is not integer")
-		}
-		totalPower += pow
+	args := strings.Fields(fmt.Sprintf(
+		cosmosWeightedVoteCmdArgs, id, options, cv.voterWallet, cv.feeFlags(), cv.chainId))
+	return cv.send(ctx, args, "weighted vote")
+}
+
+func (cv *CosmosVoter) voteArgs(id string, option VoteOption) []string {
+	return strings.Fields(fmt.Sprintf(
+		cosmosVoteCmdArgs, id, option, cv.voterWallet, cv.feeFlags(), cv.chainId))
+}
+
+// send broadcasts tx unless its fee is estimated over the max fee
+func (cv *CosmosVoter) send(ctx context.Context, args []string, txName string) (*VoteResult, error) {
+	if err := cv.checkFee(ctx, args, txName); err != nil {
+		return nil, err
 	}
-	return totalPower, nil
+	return cv.broadcast(ctx, args, []byte(cv.keychainPass), txName)
 }
 
 func logCmdErr(cmd string, args []string, stdout []byte, stderr []byte, err error) {
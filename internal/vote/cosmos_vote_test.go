@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	gomock "github.com/golang/mock/gomock"
 	"github.com/kostage/cosmos_voter/internal/cmdrunner"
@@ -15,6 +16,15 @@ import (
 //go:embed example_proposals.json
 var example_proposals []byte
 
+//go:embed example_proposals_page1.json
+var example_proposals_page1 []byte
+
+//go:embed example_proposals_page2.json
+var example_proposals_page2 []byte
+
+//go:embed example_vote.json
+var example_vote []byte
+
 //go:embed example_vote_291.json
 var example_vote_291 []byte
 
@@ -24,37 +34,177 @@ var example_vote_294 []byte
 //go:embed example_vote_295.json
 var example_vote_295 []byte
 
+//go:embed example_vote_abstain.json
+var example_vote_abstain []byte
+
+//go:embed example_vote_veto.json
+var example_vote_veto []byte
+
+//go:embed example_vote_weighted.json
+var example_vote_weighted []byte
+
 //go:embed example_tally.json
 var example_tally []byte
 
-//go:embed example_validators.yaml
-var example_validators []byte
+//go:embed example_tally_wei.json
+var example_tally_wei []byte
+
+//go:embed example_staking_pool.json
+var example_staking_pool []byte
+
+//go:embed example_gov_params.json
+var example_gov_params []byte
+
+//go:embed example_tx_broadcast.json
+var example_tx_broadcast []byte
+
+//go:embed example_tx_broadcast_rejected.json
+var example_tx_broadcast_rejected []byte
+
+//go:embed example_tx.json
+var example_tx []byte
+
+const (
+	exampleTxHash = "8A3C9E0F1B2D4C6E8A0B1C2D3E4F5A6B7C8D9E0F1A2B3C4D5E6F7A8B9C0D1E2F"
+)
+
+// expectTxIncluded expects the tx of example broadcast response to be found on the first poll
+func expectTxIncluded(runner *cmdrunner.MockCmdRunner) *gomock.Call {
+	txPollInterval = time.Millisecond
+	expectedArgs := []string{"query", "tx", exampleTxHash, "-o", "json"}
+	return runner.EXPECT().Run(gomock.Any(), "daemon", expectedArgs, nil).Return(example_tx, nil, nil)
+}
 
 func TestGetCosmosProposals(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	runner := cmdrunner.NewMockCmdRunner(ctrl)
 	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
-	expectedPropArgs := []string{"query", "gov", "proposals", "--status", "VotingPeriod", "-o", "json"}
+	expectedPropArgs := []string{"query", "gov", "proposals", "--status", "VotingPeriod", "--limit", "100", "-o", "json"}
 	expectedTallyArgs1 := []string{"query", "gov", "tally", "291", "-o", "json"}
 	expectedTallyArgs2 := []string{"query", "gov", "tally", "294", "-o", "json"}
 	expectedTallyArgs3 := []string{"query", "gov", "tally", "295", "-o", "json"}
 	expectedVoteArgs1 := []string{"query", "gov", "vote", "291", "-o", "json"}
 	expectedVoteArgs2 := []string{"query", "gov", "vote", "294", "-o", "json"}
 	expectedVoteArgs3 := []string{"query", "gov", "vote", "295", "-o", "json"}
-	expectedGetValidatotsArgs := []string{"query", "tendermint-validator-set"}
+	expectedPoolArgs := []string{"query", "staking", "pool", "-o", "json"}
+	expectedParamsArgs := []string{"query", "gov", "params", "-o", "json"}
 	runner.EXPECT().Run(gomock.Any(), "daemon", expectedPropArgs, nil).Return(example_proposals, nil, nil)
 	runner.EXPECT().Run(gomock.Any(), "daemon", expectedTallyArgs1, nil).Return(example_tally, nil, nil)
 	runner.EXPECT().Run(gomock.Any(), "daemon", expectedTallyArgs2, nil).Return(example_tally, nil, nil)
 	runner.EXPECT().Run(gomock.Any(), "daemon", expectedTallyArgs3, nil).Return(example_tally, nil, nil)
-	runner.EXPECT().Run(gomock.Any(), "daemon", expectedVoteArgs1, nil).Return(example_vote_291, nil, nil)
-	runner.EXPECT().Run(gomock.Any(), "daemon", expectedVoteArgs2, nil).Return(example_vote_294, nil, nil)
-	runner.EXPECT().Run(gomock.Any(), "daemon", expectedVoteArgs3, nil).Return(example_vote_295, nil, nil)
-	runner.EXPECT().Run(gomock.Any(), "daemon", expectedGetValidatotsArgs, nil).Return(example_validators, nil, nil)
+	runner.EXPECT().Run(gomock.Any(), "daemon", expectedVoteArgs1, nil).Return(nil, nil, fmt.Errorf("not found"))
+	runner.EXPECT().Run(gomock.Any(), "daemon", expectedVoteArgs2, nil).Return(nil, nil, fmt.Errorf("not found"))
+	runner.EXPECT().Run(gomock.Any(), "daemon", expectedVoteArgs3, nil).Return(nil, nil, fmt.Errorf("not found"))
+	runner.EXPECT().Run(gomock.Any(), "daemon", expectedPoolArgs, nil).Return(example_staking_pool, nil, nil)
+	runner.EXPECT().Run(gomock.Any(), "daemon", expectedParamsArgs, nil).Return(example_gov_params, nil, nil)
 
 	voter := NewCosmosVoter("daemon", "password", "", "", "")
 	proposals, err := voter.GetVoting(context.Background())
 	assert.NoError(t, err)
 	assert.Len(t, proposals, 3)
+	assert.Equal(t, 42.3, proposals[0].Voted)
+	assert.Equal(t, 33.4, proposals[0].Quorum)
+	assert.Equal(t, 50.0, proposals[0].Threshold)
+	assert.Equal(t, 33.4, proposals[0].VetoThreshold)
+	assert.True(t, proposals[0].WouldPass)
+	assert.Equal(t, []ProposalMessage{{
+		Type:        "/cosmos.gov.v1.MsgExecLegacyContent",
+		ContentType: "/cosmwasm.wasm.v1.InstantiateContractProposal",
+		Authority:   "kujira10d07y265gmmuvt4z0w9aw880jnsr700jt23ame",
+		Admin:       "kujira1tsekaqv9vmem0zwskmf90gpf0twl6k57e8vdnq",
+		CodeID:      "106",
+	}}, proposals[0].Messages)
+	assert.Equal(t, []Coin{{Denom: "ukuji", Amount: "10000000000"}}, proposals[0].Deposit)
+}
+
+func TestCosmosTally(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	expectedTallyArgs := []string{"query", "gov", "tally", "291", "-o", "json"}
+	expectedPoolArgs := []string{"query", "staking", "pool", "-o", "json"}
+	expectedParamsArgs := []string{"query", "gov", "params", "-o", "json"}
+	runner.EXPECT().Run(gomock.Any(), "daemon", expectedTallyArgs, nil).Return(example_tally, nil, nil)
+	runner.EXPECT().Run(gomock.Any(), "daemon", expectedPoolArgs, nil).Return(example_staking_pool, nil, nil)
+	runner.EXPECT().Run(gomock.Any(), "daemon", expectedParamsArgs, nil).Return(example_gov_params, nil, nil)
+
+	// tally is queried regardless of the vote of the wallet
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "", "")
+	tally, err := voter.Tally(context.Background(), "291")
+	assert.NoError(t, err)
+	assert.Equal(t, 42.3, tally.Voted)
+	assert.Equal(t, 33.4, tally.Quorum)
+	assert.True(t, tally.WouldPass)
+}
+
+func TestGetCosmosProposalsPaginated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	expectedPage1Args := []string{"query", "gov", "proposals", "--status", "VotingPeriod", "--limit", "100", "-o", "json"}
+	expectedPage2Args := append(append([]string{}, expectedPage1Args...), "--page", "2")
+	gomock.InOrder(
+		runner.EXPECT().Run(gomock.Any(), "daemon", expectedPage1Args, nil).Return(example_proposals_page1, nil, nil),
+		runner.EXPECT().Run(gomock.Any(), "daemon", expectedPage2Args, nil).Return(example_proposals_page2, nil, nil),
+	)
+
+	querier := &cliQuerier{daemonPath: "daemon"}
+	proposals, err := querier.proposals(context.Background())
+	assert.NoError(t, err)
+	ids := []string{}
+	for _, prop := range proposals {
+		ids = append(ids, prop.ProposalID)
+	}
+	assert.Equal(t, []string{"291", "294", "295"}, ids)
+	// second page is parsed like the first one
+	assert.Equal(t, "GHOST: Instantiate DOT Vault", proposals[2].Messages[0].Content.Title)
+	assert.Equal(t, "106", proposals[2].Messages[0].Content.CodeID)
+}
+
+func TestQueryAllPagesStuck(t *testing.T) {
+	pages := 0
+	_, err := queryAllPages(func(pageKey string) ([]int, string, error) {
+		pages++
+		return []int{pages}, "same", nil
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 2, pages)
+}
+
+func TestTallyParamsWouldPass(t *testing.T) {
+	params := tallyParams{quorum: 0.334, threshold: 0.5, vetoThreshold: 0.334}
+	for _, tc := range []struct {
+		name   string
+		tally  cosmosTallyResponse
+		passes bool
+	}{
+		{"passes", cosmosTallyResponse{Yes: 30, No: 10, Abstain: 10}, true},
+		{"no quorum", cosmosTallyResponse{Yes: 30}, false},
+		{"all abstain", cosmosTallyResponse{Abstain: 50}, false},
+		{"vetoed", cosmosTallyResponse{Yes: 30, NoWithVeto: 20}, false},
+		{"rejected", cosmosTallyResponse{Yes: 20, No: 20, Abstain: 10}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.passes, params.wouldPass(&tc.tally, 100))
+		})
+	}
+}
+
+func TestCosmosTallyWeiScale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	expectedArgs := []string{"query", "gov", "tally", "1", "-o", "json"}
+	runner.EXPECT().Run(gomock.Any(), "daemon", expectedArgs, nil).Return(example_tally_wei, nil, nil)
+
+	querier := &cliQuerier{daemonPath: "daemon"}
+	tally, err := querier.tally(context.Background(), "1")
+	assert.NoError(t, err)
+	// 18 decimal counts exceed int64
+	assert.Equal(t, 4.125e25, tally.Yes)
+	assert.Equal(t, 9.75e24, tally.No)
+	params := tallyParams{quorum: 0.334, threshold: 0.5, vetoThreshold: 0.334}
+	assert.True(t, params.wouldPass(tally, 1e26))
 }
 
 func TestGetCosmosVoted(t *testing.T) {
@@ -67,7 +217,24 @@ func TestGetCosmosVoted(t *testing.T) {
 	voter := NewCosmosVoter("daemon", "password", "voterWallet", "", "")
 	voted, err := voter.HasVoted(context.Background(), "1")
 	assert.NoError(t, err)
-	assert.False(t, voted)
+	assert.True(t, voted)
+}
+
+func TestGetCosmosVotedAllOptions(t *testing.T) {
+	for _, example := range [][]byte{
+		example_vote, example_vote_291, example_vote_abstain, example_vote_veto,
+	} {
+		ctrl := gomock.NewController(t)
+		runner := cmdrunner.NewMockCmdRunner(ctrl)
+		defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+		expectedArgs := []string{"query", "gov", "vote", "1", "voterWallet", "-o", "json"}
+		runner.EXPECT().Run(gomock.Any(), "daemon", expectedArgs, nil).Return(example, nil, nil)
+
+		voter := NewCosmosVoter("daemon", "password", "voterWallet", "", "")
+		voted, err := voter.HasVoted(context.Background(), "1")
+		assert.NoError(t, err)
+		assert.True(t, voted)
+	}
 }
 
 func TestGetCosmosNotVoted(t *testing.T) {
@@ -75,7 +242,8 @@ func TestGetCosmosNotVoted(t *testing.T) {
 	runner := cmdrunner.NewMockCmdRunner(ctrl)
 	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
 	expectedArgs := []string{"query", "gov", "vote", "1", "voterWallet", "-o", "json"}
-	runner.EXPECT().Run(gomock.Any(), "daemon", expectedArgs, nil).Return(nil, nil, fmt.Errorf("somerr"))
+	stderr := []byte("Error: rpc error: code = InvalidArgument desc = voter: voterWallet not found for proposal: 1: invalid request")
+	runner.EXPECT().Run(gomock.Any(), "daemon", expectedArgs, nil).Return(nil, stderr, fmt.Errorf("exit status 1"))
 
 	voter := NewCosmosVoter("daemon", "password", "voterWallet", "", "")
 	voted, err := voter.HasVoted(context.Background(), "1")
@@ -83,6 +251,20 @@ func TestGetCosmosNotVoted(t *testing.T) {
 	assert.False(t, voted)
 }
 
+func TestGetCosmosVotedQueryFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	expectedArgs := []string{"query", "gov", "vote", "1", "voterWallet", "-o", "json"}
+	stderr := []byte("Error: post failed: dial tcp 127.0.0.1:26657: connect: connection refused")
+	runner.EXPECT().Run(gomock.Any(), "daemon", expectedArgs, nil).Return(nil, stderr, fmt.Errorf("exit status 1"))
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "", "")
+	voted, err := voter.HasVoted(context.Background(), "1")
+	assert.Error(t, err)
+	assert.False(t, voted)
+}
+
 func TestGetCosmosVotedParseFailed(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	runner := cmdrunner.NewMockCmdRunner(ctrl)
@@ -95,3 +277,103 @@ func TestGetCosmosVotedParseFailed(t *testing.T) {
 	assert.Error(t, err)
 	assert.False(t, voted)
 }
+
+func TestCosmosVote(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	expectedArgs := []string{
+		"tx", "gov", "vote", "1", "no_with_veto", "--from", "voterWallet",
+		"--fees", "250ukuji", "--chain-id", "kaiyo-1", "-y", "-o", "json",
+	}
+	gomock.InOrder(
+		runner.EXPECT().Run(gomock.Any(), "daemon", expectedArgs, []byte("password")).Return(example_tx_broadcast, nil, nil),
+		expectTxIncluded(runner),
+	)
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "250ukuji", "kaiyo-1")
+	result, err := voter.Vote(context.Background(), "1", VoteOptionNoWithVeto)
+	assert.NoError(t, err)
+	assert.Equal(t, &VoteResult{
+		TxHash:  exampleTxHash,
+		Height:  14532761,
+		GasUsed: 84213,
+		Fee:     "250ukuji",
+	}, result)
+}
+
+func TestParseVoteOption(t *testing.T) {
+	for _, opt := range []VoteOption{
+		VoteOptionYes, VoteOptionAbstain, VoteOptionNo, VoteOptionNoWithVeto,
+	} {
+		parsed, err := ParseVoteOption(opt.String())
+		assert.NoError(t, err)
+		assert.Equal(t, opt, parsed)
+		parsed, err = ParseVoteOption(opt.CosmosString())
+		assert.NoError(t, err)
+		assert.Equal(t, opt, parsed)
+	}
+	_, err := ParseVoteOption("skip")
+	assert.Error(t, err)
+}
+
+func TestGetCosmosVotedWeighted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	expectedArgs := []string{"query", "gov", "vote", "1", "voterWallet", "-o", "json"}
+	runner.EXPECT().Run(gomock.Any(), "daemon", expectedArgs, nil).Return(example_vote_weighted, nil, nil)
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "", "")
+	voted, err := voter.HasVoted(context.Background(), "1")
+	assert.NoError(t, err)
+	assert.True(t, voted)
+}
+
+func TestCosmosWeightedVote(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	expectedArgs := []string{
+		"tx", "gov", "weighted-vote", "1", "yes=0.7,abstain=0.3", "--from", "voterWallet",
+		"--fees", "250ukuji", "--chain-id", "kaiyo-1", "-y", "-o", "json",
+	}
+	gomock.InOrder(
+		runner.EXPECT().Run(gomock.Any(), "daemon", expectedArgs, []byte("password")).Return(example_tx_broadcast, nil, nil),
+		expectTxIncluded(runner),
+	)
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "250ukuji", "kaiyo-1")
+	options := WeightedVoteOptions{
+		{Option: VoteOptionYes, Weight: 0.7},
+		{Option: VoteOptionAbstain, Weight: 0.3},
+	}
+	_, err := voter.WeightedVote(context.Background(), "1", options)
+	assert.NoError(t, err)
+	_, err = voter.WeightedVote(context.Background(), "1", options[:1])
+	assert.Error(t, err)
+}
+
+func TestParseWeightedVoteOptions(t *testing.T) {
+	options, err := ParseWeightedVoteOptions("yes=0.7,abstain=0.2,no_with_veto=0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, WeightedVoteOptions{
+		{Option: VoteOptionYes, Weight: 0.7},
+		{Option: VoteOptionAbstain, Weight: 0.2},
+		{Option: VoteOptionNoWithVeto, Weight: 0.1},
+	}, options)
+	assert.Equal(t, "yes=0.7,abstain=0.2,no_with_veto=0.1", options.String())
+
+	for _, invalid := range []string{
+		"",
+		"yes",
+		"yes=0.7",
+		"yes=0.7,skip=0.3",
+		"yes=0.7,yes=0.3",
+		"yes=1.3,no=-0.3",
+		"yes=abc,no=0.3",
+	} {
+		_, err := ParseWeightedVoteOptions(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
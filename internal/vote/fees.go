@@ -0,0 +1,159 @@
+package vote
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultGasAdjustment = 1.5
+)
+
+var (
+	ErrFeeTooHigh = fmt.Errorf("estimated fee exceeds max fee")
+
+	gasEstimateRegexp = regexp.MustCompile(`gas estimate: ([0-9]+)`)
+	decCoinRegexp     = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)([a-zA-Z][a-zA-Z0-9/:._-]*)$`)
+)
+
+// FeeEstimator simulates vote txs to tell their fee before voting
+type FeeEstimator interface {
+	// EstimateFee returns fee of a vote on proposal, weighted votes cost about the same
+	EstimateFee(ctx context.Context, id string) (*FeeEstimate, error)
+}
+
+type FeeEstimate struct {
+	// simulated gas with adjustment, 0 if fees are static
+	Gas int64
+	// fee like 2500ukuji
+	Fee string
+}
+
+func (e *FeeEstimate) String() string {
+	if e.Gas == 0 {
+		return e.Fee
+	}
+	return fmt.Sprintf("%s (gas %d)", e.Fee, e.Gas)
+}
+
+// decCoin is a coin with decimal amount like gas price 0.025ukuji
+type decCoin struct {
+	amount *big.Rat
+	denom  string
+	// as configured
+	text string
+}
+
+func parseDecCoin(s string) (*decCoin, error) {
+	parts := decCoinRegexp.FindStringSubmatch(strings.TrimSpace(s))
+	if parts == nil {
+		return nil, fmt.Errorf("'%s' is not a coin like 0.025ukuji", s)
+	}
+	amount, ok := new(big.Rat).SetString(parts[1])
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a coin like 0.025ukuji", s)
+	}
+	return &decCoin{amount: amount, denom: parts[2], text: s}, nil
+}
+
+// UseGasPrices makes voter simulate gas of txs and pay gas times price instead
+// of static fees, txs with fee estimated over maxFee are not sent, maxFee may be empty
+func (cv *CosmosVoter) UseGasPrices(gasPrices string, gasAdjustment float64, maxFee string) error {
+	price, err := parseDecCoin(gasPrices)
+	if err != nil {
+		return fmt.Errorf("invalid gas prices: %v", err)
+	}
+	if gasAdjustment == 0 {
+		gasAdjustment = defaultGasAdjustment
+	}
+	if gasAdjustment < 1 {
+		return fmt.Errorf("gas adjustment %v is less than 1", gasAdjustment)
+	}
+	var ceiling *decCoin
+	if maxFee != "" {
+		if ceiling, err = parseDecCoin(maxFee); err != nil {
+			return fmt.Errorf("invalid max fee: %v", err)
+		}
+		if ceiling.denom != price.denom {
+			return fmt.Errorf("max fee denom %s differs from gas price denom %s", ceiling.denom, price.denom)
+		}
+	}
+	cv.gasPrice = price
+	cv.gasAdjustment = gasAdjustment
+	cv.maxFee = ceiling
+	return nil
+}
+
+// feeFlags returns either static fees or gas simulation flags of tx commands
+func (cv *CosmosVoter) feeFlags() string {
+	if cv.gasPrice == nil {
+		return "--fees " + cv.fees
+	}
+	return fmt.Sprintf("--gas auto --gas-adjustment %s --gas-prices %s",
+		strconv.FormatFloat(cv.gasAdjustment, 'f', -1, 64), cv.gasPrice.text)
+}
+
+func (cv *CosmosVoter) EstimateFee(ctx context.Context, id string) (*FeeEstimate, error) {
+	if cv.gasPrice == nil {
+		return &FeeEstimate{Fee: cv.fees}, nil
+	}
+	if cv.granter != "" {
+		// authz exec needs the generated inner tx to simulate
+		return cv.estimateAuthzFee(ctx, strings.Fields(fmt.Sprintf(
+			cosmosGenerateVoteCmdArgs, id, VoteOptionYes, cv.granter, cv.chainId)))
+	}
+	return cv.simulate(ctx, cv.voteArgs(id, VoteOptionYes))
+}
+
+// simulate runs tx command in dry run mode, which prints gas estimate with adjustment
+func (cv *CosmosVoter) simulate(ctx context.Context, args []string) (*FeeEstimate, error) {
+	args = append(append([]string{}, args...), "--dry-run")
+	runner := defRunnerFactory()
+	stdout, stderr, err := runner.Run(ctx, cv.daemonPath, args, []byte(cv.keychainPass))
+	if err != nil {
+		logCmdErr(cv.daemonPath, args, stdout, stderr, err)
+		return nil, fmt.Errorf("failed to simulate tx: %v", err)
+	}
+	match := gasEstimateRegexp.FindSubmatch(append(stderr, stdout...))
+	if match == nil {
+		logCmdErr(cv.daemonPath, args, stdout, stderr, err)
+		return nil, fmt.Errorf("no gas estimate in simulation output")
+	}
+	gas, err := strconv.ParseInt(string(match[1]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("gas estimate '%s' is not integer", match[1])
+	}
+	return &FeeEstimate{Gas: gas, Fee: cv.gasFee(gas).String() + cv.gasPrice.denom}, nil
+}
+
+// gasFee rounds gas times price up like the sdk does
+func (cv *CosmosVoter) gasFee(gas int64) *big.Int {
+	return ceilInt(new(big.Rat).Mul(cv.gasPrice.amount, new(big.Rat).SetInt64(gas)))
+}
+
+func ceilInt(x *big.Rat) *big.Int {
+	amount := new(big.Int).Quo(x.Num(), x.Denom())
+	if !x.IsInt() {
+		amount.Add(amount, big.NewInt(1))
+	}
+	return amount
+}
+
+// checkFee refuses txs whose simulated fee exceeds max fee
+func (cv *CosmosVoter) checkFee(ctx context.Context, args []string, txName string) error {
+	if cv.gasPrice == nil || cv.maxFee == nil {
+		return nil
+	}
+	estimate, err := cv.simulate(ctx, args)
+	if err != nil {
+		return fmt.Errorf("%s tx pre-flight failed: %v", txName, err)
+	}
+	if new(big.Rat).SetInt(cv.gasFee(estimate.Gas)).Cmp(cv.maxFee.amount) > 0 {
+		return fmt.Errorf("%s tx fee %s over %s: %w", txName, estimate, cv.maxFee.text, ErrFeeTooHigh)
+	}
+	return nil
+}
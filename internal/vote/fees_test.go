@@ -0,0 +1,97 @@
+package vote
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/kostage/cosmos_voter/internal/cmdrunner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	expectedGasVoteArgs = []string{
+		"tx", "gov", "vote", "1", "yes", "--from", "voterWallet", "--gas", "auto", "--gas-adjustment", "1.3",
+		"--gas-prices", "0.00125ukuji", "--chain-id", "kaiyo-1",
+	}
+	// cli prints estimate with adjustment to stderr
+	exampleGasEstimate = []byte("gas estimate: 109477\n")
+)
+
+func TestCosmosEstimateFee(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	expectedArgs := append(append([]string{}, expectedGasVoteArgs...), "--dry-run")
+	runner.EXPECT().Run(gomock.Any(), "daemon", expectedArgs, []byte("password")).Return(nil, exampleGasEstimate, nil)
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "250ukuji", "kaiyo-1")
+	estimate, err := voter.EstimateFee(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, &FeeEstimate{Fee: "250ukuji"}, estimate)
+
+	require.NoError(t, voter.UseGasPrices("0.00125ukuji", 1.3, ""))
+	estimate, err = voter.EstimateFee(context.Background(), "1")
+	require.NoError(t, err)
+	// 109477 * 0.00125 = 136.84625 rounded up
+	assert.Equal(t, &FeeEstimate{Gas: 109477, Fee: "137ukuji"}, estimate)
+	assert.Equal(t, "137ukuji (gas 109477)", estimate.String())
+}
+
+func TestCosmosEstimateFeeNoEstimate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), gomock.Any()).Return([]byte("{}"), nil, nil)
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "", "kaiyo-1")
+	require.NoError(t, voter.UseGasPrices("0.00125ukuji", 0, ""))
+	_, err := voter.EstimateFee(context.Background(), "1")
+	assert.Error(t, err)
+}
+
+func TestCosmosVoteGasPrices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	gomock.InOrder(
+		runner.EXPECT().Run(gomock.Any(), "daemon", append(append([]string{}, expectedGasVoteArgs...), "--dry-run"), []byte("password")).
+			Return(nil, exampleGasEstimate, nil),
+		runner.EXPECT().Run(gomock.Any(), "daemon", append(append([]string{}, expectedGasVoteArgs...), "-y", "-o", "json"), []byte("password")).
+			Return(example_tx_broadcast, nil, nil),
+		expectTxIncluded(runner),
+	)
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "250ukuji", "kaiyo-1")
+	require.NoError(t, voter.UseGasPrices("0.00125ukuji", 1.3, "137ukuji"))
+	result, err := voter.Vote(context.Background(), "1", VoteOptionYes)
+	assert.NoError(t, err)
+	assert.Equal(t, exampleTxHash, result.TxHash)
+}
+
+func TestCosmosVoteFeeTooHigh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	// only the simulation runs, nothing is broadcast
+	runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), []byte("password")).Return(nil, exampleGasEstimate, nil)
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "250ukuji", "kaiyo-1")
+	require.NoError(t, voter.UseGasPrices("0.00125ukuji", 1.3, "136ukuji"))
+	_, err := voter.Vote(context.Background(), "1", VoteOptionYes)
+	assert.True(t, errors.Is(err, ErrFeeTooHigh), err)
+}
+
+func TestUseGasPrices(t *testing.T) {
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "250ukuji", "kaiyo-1")
+	assert.Error(t, voter.UseGasPrices("ukuji", 0, ""))
+	assert.Error(t, voter.UseGasPrices("0.025", 0, ""))
+	assert.Error(t, voter.UseGasPrices("0.025ukuji", 0.5, ""))
+	assert.Error(t, voter.UseGasPrices("0.025ukuji", 0, "5000uatom"))
+	assert.Equal(t, "--fees 250ukuji", voter.feeFlags())
+
+	require.NoError(t, voter.UseGasPrices("0.025ukuji", 0, "5000ukuji"))
+	assert.Equal(t, "--gas auto --gas-adjustment 1.5 --gas-prices 0.025ukuji", voter.feeFlags())
+}
@@ -0,0 +1,541 @@
+package vote
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	grpcProposalsMethod   = "/cosmos.gov.v1.Query/Proposals"
+	grpcVoteMethod        = "/cosmos.gov.v1.Query/Vote"
+	grpcTallyMethod       = "/cosmos.gov.v1.Query/TallyResult"
+	grpcParamsMethod      = "/cosmos.gov.v1.Query/Params"
+	grpcStakingPoolMethod = "/cosmos.staking.v1beta1.Query/Pool"
+	grpcAuthzGrantsMethod = "/cosmos.authz.v1beta1.Query/Grants"
+	grpcBalanceMethod     = "/cosmos.bank.v1beta1.Query/Balance"
+
+	grpcExecLegacyContentType = "/cosmos.gov.v1.MsgExecLegacyContent"
+	grpcVotingPeriodStatus    = 2
+	grpcTallyParamsType       = "tallying"
+)
+
+var (
+	grpcWasmInstantiateTypes = map[string]bool{
+		"/cosmwasm.wasm.v1.InstantiateContractProposal":  true,
+		"/cosmwasm.wasm.v1.InstantiateContract2Proposal": true,
+	}
+)
+
+// Messages below mirror cosmos gov v1, staking, authz and bank v1beta1 protos, only fields
+// used by the bot are declared, the rest is skipped on unmarshal
+
+type grpcAny struct {
+	TypeUrl string `protobuf:"bytes,1,opt,name=type_url,proto3"`
+	Value   []byte `protobuf:"bytes,2,opt,name=value,proto3"`
+}
+
+type grpcTimestamp struct {
+	Seconds int64 `protobuf:"varint,1,opt,name=seconds,proto3"`
+	Nanos   int32 `protobuf:"varint,2,opt,name=nanos,proto3"`
+}
+
+type grpcPageRequest struct {
+	Key   []byte `protobuf:"bytes,1,opt,name=key,proto3"`
+	Limit uint64 `protobuf:"varint,3,opt,name=limit,proto3"`
+}
+
+type grpcPageResponse struct {
+	NextKey []byte `protobuf:"bytes,1,opt,name=next_key,proto3"`
+}
+
+type grpcProposalsRequest struct {
+	ProposalStatus int32            `protobuf:"varint,1,opt,name=proposal_status,proto3"`
+	Pagination     *grpcPageRequest `protobuf:"bytes,4,opt,name=pagination,proto3"`
+}
+
+type grpcProposalsResponse struct {
+	Proposals  []*grpcProposal   `protobuf:"bytes,1,rep,name=proposals,proto3"`
+	Pagination *grpcPageResponse `protobuf:"bytes,2,opt,name=pagination,proto3"`
+}
+
+type grpcProposal struct {
+	Id            uint64         `protobuf:"varint,1,opt,name=id,proto3"`
+	Messages      []*grpcAny     `protobuf:"bytes,2,rep,name=messages,proto3"`
+	TotalDeposit  []*grpcCoin    `protobuf:"bytes,7,rep,name=total_deposit,proto3"`
+	VotingEndTime *grpcTimestamp `protobuf:"bytes,9,opt,name=voting_end_time,proto3"`
+	Title         string         `protobuf:"bytes,11,opt,name=title,proto3"`
+	Summary       string         `protobuf:"bytes,12,opt,name=summary,proto3"`
+	Proposer      string         `protobuf:"bytes,13,opt,name=proposer,proto3"`
+}
+
+type grpcCoin struct {
+	Denom  string `protobuf:"bytes,1,opt,name=denom,proto3"`
+	Amount string `protobuf:"bytes,2,opt,name=amount,proto3"`
+}
+
+type grpcExecLegacyContent struct {
+	Content   *grpcAny `protobuf:"bytes,1,opt,name=content,proto3"`
+	Authority string   `protobuf:"bytes,2,opt,name=authority,proto3"`
+}
+
+// grpcLegacyContent matches title and description of all legacy proposal contents
+type grpcLegacyContent struct {
+	Title       string `protobuf:"bytes,1,opt,name=title,proto3"`
+	Description string `protobuf:"bytes,2,opt,name=description,proto3"`
+}
+
+// grpcWasmInstantiateContent matches wasm InstantiateContractProposal and
+// InstantiateContract2Proposal
+type grpcWasmInstantiateContent struct {
+	Admin  string `protobuf:"bytes,4,opt,name=admin,proto3"`
+	CodeId uint64 `protobuf:"varint,5,opt,name=code_id,proto3"`
+}
+
+type grpcVoteRequest struct {
+	ProposalId uint64 `protobuf:"varint,1,opt,name=proposal_id,proto3"`
+	Voter      string `protobuf:"bytes,2,opt,name=voter,proto3"`
+}
+
+type grpcVoteResponse struct {
+	Vote *grpcVote `protobuf:"bytes,1,opt,name=vote,proto3"`
+}
+
+type grpcVote struct {
+	Options []*grpcWeightedVoteOption `protobuf:"bytes,4,rep,name=options,proto3"`
+}
+
+type grpcWeightedVoteOption struct {
+	Option int32  `protobuf:"varint,1,opt,name=option,proto3"`
+	Weight string `protobuf:"bytes,2,opt,name=weight,proto3"`
+}
+
+type grpcTallyRequest struct {
+	ProposalId uint64 `protobuf:"varint,1,opt,name=proposal_id,proto3"`
+}
+
+type grpcTallyResponse struct {
+	Tally *grpcTally `protobuf:"bytes,1,opt,name=tally,proto3"`
+}
+
+type grpcTally struct {
+	YesCount        string `protobuf:"bytes,1,opt,name=yes_count,proto3"`
+	AbstainCount    string `protobuf:"bytes,2,opt,name=abstain_count,proto3"`
+	NoCount         string `protobuf:"bytes,3,opt,name=no_count,proto3"`
+	NoWithVetoCount string `protobuf:"bytes,4,opt,name=no_with_veto_count,proto3"`
+}
+
+type grpcParamsRequest struct {
+	ParamsType string `protobuf:"bytes,1,opt,name=params_type,proto3"`
+}
+
+type grpcParamsResponse struct {
+	TallyParams *grpcTallyParams `protobuf:"bytes,3,opt,name=tally_params,proto3"`
+	Params      *grpcParams      `protobuf:"bytes,4,opt,name=params,proto3"`
+}
+
+type grpcTallyParams struct {
+	Quorum        string `protobuf:"bytes,1,opt,name=quorum,proto3"`
+	Threshold     string `protobuf:"bytes,2,opt,name=threshold,proto3"`
+	VetoThreshold string `protobuf:"bytes,3,opt,name=veto_threshold,proto3"`
+}
+
+type grpcParams struct {
+	Quorum        string `protobuf:"bytes,4,opt,name=quorum,proto3"`
+	Threshold     string `protobuf:"bytes,5,opt,name=threshold,proto3"`
+	VetoThreshold string `protobuf:"bytes,6,opt,name=veto_threshold,proto3"`
+}
+
+type grpcStakingPoolRequest struct{}
+
+type grpcStakingPoolResponse struct {
+	Pool *grpcStakingPool `protobuf:"bytes,1,opt,name=pool,proto3"`
+}
+
+type grpcStakingPool struct {
+	NotBondedTokens string `protobuf:"bytes,1,opt,name=not_bonded_tokens,proto3"`
+	BondedTokens    string `protobuf:"bytes,2,opt,name=bonded_tokens,proto3"`
+}
+
+type grpcGrantsRequest struct {
+	Granter    string `protobuf:"bytes,1,opt,name=granter,proto3"`
+	Grantee    string `protobuf:"bytes,2,opt,name=grantee,proto3"`
+	MsgTypeUrl string `protobuf:"bytes,3,opt,name=msg_type_url,proto3"`
+}
+
+type grpcGrantsResponse struct {
+	Grants []*grpcGrant `protobuf:"bytes,1,rep,name=grants,proto3"`
+}
+
+type grpcGrant struct {
+	Authorization *grpcAny       `protobuf:"bytes,1,opt,name=authorization,proto3"`
+	Expiration    *grpcTimestamp `protobuf:"bytes,2,opt,name=expiration,proto3"`
+}
+
+type grpcBalanceRequest struct {
+	Address string `protobuf:"bytes,1,opt,name=address,proto3"`
+	Denom   string `protobuf:"bytes,2,opt,name=denom,proto3"`
+}
+
+type grpcBalanceResponse struct {
+	Balance *grpcCoin `protobuf:"bytes,1,opt,name=balance,proto3"`
+}
+
+// grpcGenericAuthorization matches authz GenericAuthorization
+type grpcGenericAuthorization struct {
+	Msg string `protobuf:"bytes,1,opt,name=msg,proto3"`
+}
+
+func (m *grpcAny) Reset()                            { *m = grpcAny{} }
+func (m *grpcAny) String() string                    { return fmt.Sprintf("%+v", *m) }
+func (*grpcAny) ProtoMessage()                       {}
+func (m *grpcTimestamp) Reset()                      { *m = grpcTimestamp{} }
+func (m *grpcTimestamp) String() string              { return fmt.Sprintf("%+v", *m) }
+func (*grpcTimestamp) ProtoMessage()                 {}
+func (m *grpcPageRequest) Reset()                    { *m = grpcPageRequest{} }
+func (m *grpcPageRequest) String() string            { return fmt.Sprintf("%+v", *m) }
+func (*grpcPageRequest) ProtoMessage()               {}
+func (m *grpcPageResponse) Reset()                   { *m = grpcPageResponse{} }
+func (m *grpcPageResponse) String() string           { return fmt.Sprintf("%+v", *m) }
+func (*grpcPageResponse) ProtoMessage()              {}
+func (m *grpcProposalsRequest) Reset()               { *m = grpcProposalsRequest{} }
+func (m *grpcProposalsRequest) String() string       { return fmt.Sprintf("%+v", *m) }
+func (*grpcProposalsRequest) ProtoMessage()          {}
+func (m *grpcProposalsResponse) Reset()              { *m = grpcProposalsResponse{} }
+func (m *grpcProposalsResponse) String() string      { return fmt.Sprintf("%+v", *m) }
+func (*grpcProposalsResponse) ProtoMessage()         {}
+func (m *grpcProposal) Reset()                       { *m = grpcProposal{} }
+func (m *grpcProposal) String() string               { return fmt.Sprintf("%+v", *m) }
+func (*grpcProposal) ProtoMessage()                  {}
+func (m *grpcCoin) Reset()                           { *m = grpcCoin{} }
+func (m *grpcCoin) String() string                   { return fmt.Sprintf("%+v", *m) }
+func (*grpcCoin) ProtoMessage()                      {}
+func (m *grpcWasmInstantiateContent) Reset()         { *m = grpcWasmInstantiateContent{} }
+func (m *grpcWasmInstantiateContent) String() string { return fmt.Sprintf("%+v", *m) }
+func (*grpcWasmInstantiateContent) ProtoMessage()    {}
+func (m *grpcExecLegacyContent) Reset()              { *m = grpcExecLegacyContent{} }
+func (m *grpcExecLegacyContent) String() string      { return fmt.Sprintf("%+v", *m) }
+func (*grpcExecLegacyContent) ProtoMessage()         {}
+func (m *grpcLegacyContent) Reset()                  { *m = grpcLegacyContent{} }
+func (m *grpcLegacyContent) String() string          { return fmt.Sprintf("%+v", *m) }
+func (*grpcLegacyContent) ProtoMessage()             {}
+func (m *grpcVoteRequest) Reset()                    { *m = grpcVoteRequest{} }
+func (m *grpcVoteRequest) String() string            { return fmt.Sprintf("%+v", *m) }
+func (*grpcVoteRequest) ProtoMessage()               {}
+func (m *grpcVoteResponse) Reset()                   { *m = grpcVoteResponse{} }
+func (m *grpcVoteResponse) String() string           { return fmt.Sprintf("%+v", *m) }
+func (*grpcVoteResponse) ProtoMessage()              {}
+func (m *grpcVote) Reset()                           { *m = grpcVote{} }
+func (m *grpcVote) String() string                   { return fmt.Sprintf("%+v", *m) }
+func (*grpcVote) ProtoMessage()                      {}
+func (m *grpcWeightedVoteOption) Reset()             { *m = grpcWeightedVoteOption{} }
+func (m *grpcWeightedVoteOption) String() string     { return fmt.Sprintf("%+v", *m) }
+func (*grpcWeightedVoteOption) ProtoMessage()        {}
+func (m *grpcTallyRequest) Reset()                   { *m = grpcTallyRequest{} }
+func (m *grpcTallyRequest) String() string           { return fmt.Sprintf("%+v", *m) }
+func (*grpcTallyRequest) ProtoMessage()              {}
+func (m *grpcTallyResponse) Reset()                  { *m = grpcTallyResponse{} }
+func (m *grpcTallyResponse) String() string          { return fmt.Sprintf("%+v", *m) }
+func (*grpcTallyResponse) ProtoMessage()             {}
+func (m *grpcTally) Reset()                          { *m = grpcTally{} }
+func (m *grpcTally) String() string                  { return fmt.Sprintf("%+v", *m) }
+func (*grpcTally) ProtoMessage()                     {}
+func (m *grpcParamsRequest) Reset()                  { *m = grpcParamsRequest{} }
+func (m *grpcParamsRequest) String() string          { return fmt.Sprintf("%+v", *m) }
+func (*grpcParamsRequest) ProtoMessage()             {}
+func (m *grpcParamsResponse) Reset()                 { *m = grpcParamsResponse{} }
+func (m *grpcParamsResponse) String() string         { return fmt.Sprintf("%+v", *m) }
+func (*grpcParamsResponse) ProtoMessage()            {}
+func (m *grpcTallyParams) Reset()                    { *m = grpcTallyParams{} }
+func (m *grpcTallyParams) String() string            { return fmt.Sprintf("%+v", *m) }
+func (*grpcTallyParams) ProtoMessage()               {}
+func (m *grpcParams) Reset()                         { *m = grpcParams{} }
+func (m *grpcParams) String() string                 { return fmt.Sprintf("%+v", *m) }
+func (*grpcParams) ProtoMessage()                    {}
+func (m *grpcStakingPoolRequest) Reset()             { *m = grpcStakingPoolRequest{} }
+func (m *grpcStakingPoolRequest) String() string     { return fmt.Sprintf("%+v", *m) }
+func (*grpcStakingPoolRequest) ProtoMessage()        {}
+func (m *grpcStakingPoolResponse) Reset()            { *m = grpcStakingPoolResponse{} }
+func (m *grpcStakingPoolResponse) String() string    { return fmt.Sprintf("%+v", *m) }
+func (*grpcStakingPoolResponse) ProtoMessage()       {}
+func (m *grpcStakingPool) Reset()                    { *m = grpcStakingPool{} }
+func (m *grpcStakingPool) String() string            { return fmt.Sprintf("%+v", *m) }
+func (*grpcStakingPool) ProtoMessage()               {}
+func (m *grpcGrantsRequest) Reset()                  { *m = grpcGrantsRequest{} }
+func (m *grpcGrantsRequest) String() string          { return fmt.Sprintf("%+v", *m) }
+func (*grpcGrantsRequest) ProtoMessage()             {}
+func (m *grpcGrantsResponse) Reset()                 { *m = grpcGrantsResponse{} }
+func (m *grpcGrantsResponse) String() string         { return fmt.Sprintf("%+v", *m) }
+func (*grpcGrantsResponse) ProtoMessage()            {}
+func (m *grpcGrant) Reset()                          { *m = grpcGrant{} }
+func (m *grpcGrant) String() string                  { return fmt.Sprintf("%+v", *m) }
+func (*grpcGrant) ProtoMessage()                     {}
+func (m *grpcGenericAuthorization) Reset()           { *m = grpcGenericAuthorization{} }
+func (m *grpcGenericAuthorization) String() string   { return fmt.Sprintf("%+v", *m) }
+func (*grpcGenericAuthorization) ProtoMessage()      {}
+func (m *grpcBalanceRequest) Reset()                 { *m = grpcBalanceRequest{} }
+func (m *grpcBalanceRequest) String() string         { return fmt.Sprintf("%+v", *m) }
+func (*grpcBalanceRequest) ProtoMessage()            {}
+func (m *grpcBalanceResponse) Reset()                { *m = grpcBalanceResponse{} }
+func (m *grpcBalanceResponse) String() string        { return fmt.Sprintf("%+v", *m) }
+func (*grpcBalanceResponse) ProtoMessage()           {}
+
+// grpcQuerier runs queries with gov v1 and staking gRPC query services of a node
+type grpcQuerier struct {
+	conn *grpc.ClientConn
+}
+
+func newGrpcQuerier(addr string, useTLS bool, opts ...grpc.DialOption) (*grpcQuerier, error) {
+	creds := insecure.NewCredentials()
+	if useTLS {
+		creds = credentials.NewTLS(&tls.Config{})
+	}
+	opts = append(opts, grpc.WithTransportCredentials(creds))
+	conn, err := grpc.Dial(addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial grpc %s: %v", addr, err)
+	}
+	return &grpcQuerier{conn: conn}, nil
+}
+
+func (q *grpcQuerier) proposals(ctx context.Context) ([]cosmosProposal, error) {
+	// page keys are raw bytes here, string just carries them between pages
+	return queryAllPages(func(pageKey string) ([]cosmosProposal, string, error) {
+		return q.proposalsPage(ctx, pageKey)
+	})
+}
+
+func (q *grpcQuerier) proposalsPage(ctx context.Context, pageKey string) ([]cosmosProposal, string, error) {
+	resp := &grpcProposalsResponse{}
+	req := &grpcProposalsRequest{
+		ProposalStatus: grpcVotingPeriodStatus,
+		Pagination:     &grpcPageRequest{Key: []byte(pageKey), Limit: pageLimit},
+	}
+	if err := q.conn.Invoke(ctx, grpcProposalsMethod, req, resp); err != nil {
+		return nil, "", fmt.Errorf("failed to query cosmos proposals: %v", err)
+	}
+	proposals := make([]cosmosProposal, 0, len(resp.Proposals))
+	for _, prop := range resp.Proposals {
+		messages, err := prop.messages()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to decode proposal %d: %v", prop.Id, err)
+		}
+		cosmosProp := cosmosProposal{
+			ProposalID: strconv.FormatUint(prop.Id, 10),
+			Messages:   messages,
+			Proposer:   prop.Proposer,
+		}
+		for _, coin := range prop.TotalDeposit {
+			cosmosProp.TotalDeposit = append(cosmosProp.TotalDeposit, cosmosCoin{Denom: coin.Denom, Amount: coin.Amount})
+		}
+		if prop.VotingEndTime != nil {
+			cosmosProp.VotingEndTime = time.Unix(
+				prop.VotingEndTime.Seconds, int64(prop.VotingEndTime.Nanos)).UTC()
+		}
+		proposals = append(proposals, cosmosProp)
+	}
+	nextKey := ""
+	if resp.Pagination != nil {
+		nextKey = string(resp.Pagination.NextKey)
+	}
+	return proposals, nextKey, nil
+}
+
+// messages decodes proposal messages, title and description are taken from
+// legacy content of the first message, falling back to proposal title and
+// summary of sdk v0.47+
+func (p *grpcProposal) messages() ([]cosmosProposalMessage, error) {
+	messages := make([]cosmosProposalMessage, 0, len(p.Messages))
+	for _, any := range p.Messages {
+		msg := cosmosProposalMessage{Type: any.TypeUrl}
+		if any.TypeUrl == grpcExecLegacyContentType {
+			if err := decodeGrpcLegacyContent(any.Value, &msg); err != nil {
+				return nil, err
+			}
+		}
+		messages = append(messages, msg)
+	}
+	if len(messages) == 0 {
+		messages = append(messages, cosmosProposalMessage{})
+	}
+	if messages[0].Content.Title == "" {
+		messages[0].Content.Title = p.Title
+		messages[0].Content.Description = p.Summary
+	}
+	return messages, nil
+}
+
+func decodeGrpcLegacyContent(value []byte, msg *cosmosProposalMessage) error {
+	exec := &grpcExecLegacyContent{}
+	if err := proto.Unmarshal(value, exec); err != nil {
+		return err
+	}
+	msg.Authority = exec.Authority
+	if exec.Content == nil {
+		return nil
+	}
+	msg.Content.Type = exec.Content.TypeUrl
+	legacy := &grpcLegacyContent{}
+	if err := proto.Unmarshal(exec.Content.Value, legacy); err != nil {
+		return err
+	}
+	msg.Content.Title = legacy.Title
+	msg.Content.Description = legacy.Description
+	if !grpcWasmInstantiateTypes[exec.Content.TypeUrl] {
+		return nil
+	}
+	wasm := &grpcWasmInstantiateContent{}
+	if err := proto.Unmarshal(exec.Content.Value, wasm); err != nil {
+		return err
+	}
+	msg.Content.Admin = wasm.Admin
+	msg.Content.CodeID = strconv.FormatUint(wasm.CodeId, 10)
+	return nil
+}
+
+func (q *grpcQuerier) vote(ctx context.Context, id string, voter string) (*cosmosHasVotedResponse, error) {
+	propID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("proposal id %s is not a number", id)
+	}
+	resp := &grpcVoteResponse{}
+	req := &grpcVoteRequest{ProposalId: propID, Voter: voter}
+	if err := q.conn.Invoke(ctx, grpcVoteMethod, req, resp); err != nil {
+		// gov module reports missing votes as InvalidArgument, as well as
+		// malformed requests which must not pass for not voted
+		st := status.Convert(err)
+		if st.Code() == codes.NotFound ||
+			(st.Code() == codes.InvalidArgument && strings.Contains(st.Message(), "not found")) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query vote: %v", err)
+	}
+	if resp.Vote == nil {
+		return nil, nil
+	}
+	hasVoted := &cosmosHasVotedResponse{}
+	for _, opt := range resp.Vote.Options {
+		hasVoted.Options = append(hasVoted.Options, cosmosVotedOption{
+			Option: VoteOption(opt.Option).CosmosString(),
+		})
+	}
+	return hasVoted, nil
+}
+
+func (q *grpcQuerier) tally(ctx context.Context, id string) (*cosmosTallyResponse, error) {
+	propID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("proposal id %s is not a number", id)
+	}
+	resp := &grpcTallyResponse{}
+	if err := q.conn.Invoke(ctx, grpcTallyMethod, &grpcTallyRequest{ProposalId: propID}, resp); err != nil {
+		return nil, fmt.Errorf("failed to query tally: %v", err)
+	}
+	if resp.Tally == nil {
+		return nil, fmt.Errorf("tally of proposal %s is empty", id)
+	}
+	tally := &cosmosTallyResponse{}
+	for _, count := range []struct {
+		value string
+		dest  *float64
+	}{
+		{resp.Tally.YesCount, &tally.Yes},
+		{resp.Tally.AbstainCount, &tally.Abstain},
+		{resp.Tally.NoCount, &tally.No},
+		{resp.Tally.NoWithVetoCount, &tally.NoWithVeto},
+	} {
+		if *count.dest, err = strconv.ParseFloat(count.value, 64); err != nil {
+			return nil, fmt.Errorf("tally count '%s' is not a number", count.value)
+		}
+	}
+	return tally, nil
+}
+
+func (q *grpcQuerier) bondedTokens(ctx context.Context) (float64, error) {
+	resp := &grpcStakingPoolResponse{}
+	if err := q.conn.Invoke(ctx, grpcStakingPoolMethod, &grpcStakingPoolRequest{}, resp); err != nil {
+		return 0, fmt.Errorf("failed to query staking pool: %v", err)
+	}
+	if resp.Pool == nil {
+		return 0, fmt.Errorf("staking pool is empty")
+	}
+	bonded, err := strconv.ParseFloat(resp.Pool.BondedTokens, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bonded tokens '%s' is not a number", resp.Pool.BondedTokens)
+	}
+	return bonded, nil
+}
+
+func (q *grpcQuerier) tallyParams(ctx context.Context) (*cosmosTallyParams, error) {
+	resp := &grpcParamsResponse{}
+	req := &grpcParamsRequest{ParamsType: grpcTallyParamsType}
+	if err := q.conn.Invoke(ctx, grpcParamsMethod, req, resp); err != nil {
+		return nil, fmt.Errorf("failed to query gov params: %v", err)
+	}
+	// tally_params is deprecated since sdk v0.47 in favour of params
+	if resp.Params != nil && resp.Params.Quorum != "" {
+		return &cosmosTallyParams{
+			Quorum:        resp.Params.Quorum,
+			Threshold:     resp.Params.Threshold,
+			VetoThreshold: resp.Params.VetoThreshold,
+		}, nil
+	}
+	if resp.TallyParams != nil && resp.TallyParams.Quorum != "" {
+		return &cosmosTallyParams{
+			Quorum:        resp.TallyParams.Quorum,
+			Threshold:     resp.TallyParams.Threshold,
+			VetoThreshold: resp.TallyParams.VetoThreshold,
+		}, nil
+	}
+	return nil, fmt.Errorf("gov params response has no tally params")
+}
+
+func (q *grpcQuerier) grants(ctx context.Context, granter string, grantee string, msgType string) ([]cosmosGrant, error) {
+	resp := &grpcGrantsResponse{}
+	req := &grpcGrantsRequest{Granter: granter, Grantee: grantee, MsgTypeUrl: msgType}
+	if err := q.conn.Invoke(ctx, grpcAuthzGrantsMethod, req, resp); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query authz grants: %v", err)
+	}
+	grants := make([]cosmosGrant, 0, len(resp.Grants))
+	for _, grant := range resp.Grants {
+		cosmosGrant := cosmosGrant{}
+		if grant.Authorization != nil {
+			cosmosGrant.Authorization.Type = grant.Authorization.TypeUrl
+			generic := &grpcGenericAuthorization{}
+			if err := proto.Unmarshal(grant.Authorization.Value, generic); err == nil {
+				cosmosGrant.Authorization.Msg = generic.Msg
+			}
+		}
+		if grant.Expiration != nil {
+			expiration := time.Unix(grant.Expiration.Seconds, int64(grant.Expiration.Nanos)).UTC()
+			cosmosGrant.Expiration = &expiration
+		}
+		grants = append(grants, cosmosGrant)
+	}
+	return grants, nil
+}
+
+func (q *grpcQuerier) balance(ctx context.Context, address string, denom string) (string, error) {
+	resp := &grpcBalanceResponse{}
+	req := &grpcBalanceRequest{Address: address, Denom: denom}
+	if err := q.conn.Invoke(ctx, grpcBalanceMethod, req, resp); err != nil {
+		return "", fmt.Errorf("failed to query balance: %v", err)
+	}
+	if resp.Balance == nil || resp.Balance.Amount == "" {
+		return "0", nil
+	}
+	return resp.Balance.Amount, nil
+}
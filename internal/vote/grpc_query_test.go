@@ -0,0 +1,273 @@
+package vote
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var (
+	testGrpcVotingEnd       = time.Date(2023, 4, 23, 13, 23, 26, 409708693, time.UTC)
+	testGrpcGrantExpiration = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// testGovServer serves canned gov, staking, authz and bank responses
+type testGovServer struct {
+	t *testing.T
+}
+
+func (s *testGovServer) proposals(req *grpcProposalsRequest) (*grpcProposalsResponse, error) {
+	assert.Equal(s.t, int32(grpcVotingPeriodStatus), req.ProposalStatus)
+	content, err := proto.Marshal(&grpcLegacyContent{
+		Title:       "GHOST: Instantiate ATOM Vault",
+		Description: "This will deploy a GHOST Vault for ATOM",
+	})
+	require.NoError(s.t, err)
+	// concatenated messages are merged on unmarshal
+	wasm, err := proto.Marshal(&grpcWasmInstantiateContent{Admin: "kujira1admin", CodeId: 106})
+	require.NoError(s.t, err)
+	exec, err := proto.Marshal(&grpcExecLegacyContent{
+		Content:   &grpcAny{TypeUrl: "/cosmwasm.wasm.v1.InstantiateContractProposal", Value: append(content, wasm...)},
+		Authority: "kujira1gov",
+	})
+	require.NoError(s.t, err)
+	votingEnd := &grpcTimestamp{
+		Seconds: testGrpcVotingEnd.Unix(),
+		Nanos:   int32(testGrpcVotingEnd.Nanosecond()),
+	}
+	return &grpcProposalsResponse{
+		Proposals: []*grpcProposal{
+			{
+				Id:            291,
+				Messages:      []*grpcAny{{TypeUrl: grpcExecLegacyContentType, Value: exec}},
+				TotalDeposit:  []*grpcCoin{{Denom: "ukuji", Amount: "10000000000"}},
+				VotingEndTime: votingEnd,
+				Proposer:      "kujira1proposer",
+			},
+			{
+				Id:            294,
+				Messages:      []*grpcAny{{TypeUrl: "/cosmos.upgrade.v1beta1.MsgSoftwareUpgrade"}},
+				VotingEndTime: votingEnd,
+				Title:         "Upgrade v0.8.4",
+				Summary:       "Upgrade kaiyo-1 to v0.8.4",
+			},
+			{
+				Id:            295,
+				VotingEndTime: votingEnd,
+			},
+		},
+	}, nil
+}
+
+func (s *testGovServer) vote(req *grpcVoteRequest) (*grpcVoteResponse, error) {
+	assert.Equal(s.t, "voterWallet", req.Voter)
+	if req.ProposalId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "proposal id can not be 0")
+	}
+	if req.ProposalId != 295 {
+		return nil, status.Errorf(codes.InvalidArgument, "voter: %s not found for proposal: %d", req.Voter, req.ProposalId)
+	}
+	return &grpcVoteResponse{
+		Vote: &grpcVote{
+			Options: []*grpcWeightedVoteOption{
+				{Option: int32(VoteOptionYes), Weight: "0.700000000000000000"},
+				{Option: int32(VoteOptionAbstain), Weight: "0.300000000000000000"},
+			},
+		},
+	}, nil
+}
+
+func (s *testGovServer) tally(req *grpcTallyRequest) (*grpcTallyResponse, error) {
+	return &grpcTallyResponse{
+		Tally: &grpcTally{
+			YesCount:        "30183768265940",
+			AbstainCount:    "3653099981504",
+			NoCount:         "29308544",
+			NoWithVetoCount: "0",
+		},
+	}, nil
+}
+
+func (s *testGovServer) params(req *grpcParamsRequest) (*grpcParamsResponse, error) {
+	assert.Equal(s.t, "tallying", req.ParamsType)
+	return &grpcParamsResponse{
+		Params: &grpcParams{
+			Quorum:        "0.400000000000000000",
+			Threshold:     "0.500000000000000000",
+			VetoThreshold: "0.334000000000000000",
+		},
+	}, nil
+}
+
+func (s *testGovServer) pool(req *grpcStakingPoolRequest) (*grpcStakingPoolResponse, error) {
+	return &grpcStakingPoolResponse{
+		Pool: &grpcStakingPool{
+			NotBondedTokens: "1000000",
+			BondedTokens:    "80000000000000",
+		},
+	}, nil
+}
+
+func (s *testGovServer) grants(req *grpcGrantsRequest) (*grpcGrantsResponse, error) {
+	assert.Equal(s.t, "voterWallet", req.Grantee)
+	assert.Contains(s.t, voteMsgTypeURLs, req.MsgTypeUrl)
+	if req.Granter != "granterWallet" {
+		return nil, status.Errorf(codes.NotFound, "no authorization found for %s type", req.MsgTypeUrl)
+	}
+	generic, err := proto.Marshal(&grpcGenericAuthorization{Msg: req.MsgTypeUrl})
+	require.NoError(s.t, err)
+	return &grpcGrantsResponse{
+		Grants: []*grpcGrant{{
+			Authorization: &grpcAny{TypeUrl: "/cosmos.authz.v1beta1.GenericAuthorization", Value: generic},
+			Expiration:    &grpcTimestamp{Seconds: testGrpcGrantExpiration.Unix()},
+		}},
+	}, nil
+}
+
+func (s *testGovServer) balance(req *grpcBalanceRequest) (*grpcBalanceResponse, error) {
+	assert.Equal(s.t, "voterWallet", req.Address)
+	if req.Denom != "ukuji" {
+		return &grpcBalanceResponse{Balance: &grpcCoin{Denom: req.Denom, Amount: "0"}}, nil
+	}
+	return &grpcBalanceResponse{Balance: &grpcCoin{Denom: "ukuji", Amount: "12480"}}, nil
+}
+
+func unaryHandler[Req any, Resp any](
+	handle func(*testGovServer, *Req) (*Resp, error),
+) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+		req := new(Req)
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		return handle(srv.(*testGovServer), req)
+	}
+}
+
+func newTestGrpcQuerier(t *testing.T) *grpcQuerier {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "cosmos.gov.v1.Query",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: "Proposals", Handler: unaryHandler((*testGovServer).proposals)},
+			{MethodName: "Vote", Handler: unaryHandler((*testGovServer).vote)},
+			{MethodName: "TallyResult", Handler: unaryHandler((*testGovServer).tally)},
+			{MethodName: "Params", Handler: unaryHandler((*testGovServer).params)},
+		},
+	}, &testGovServer{t: t})
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "cosmos.staking.v1beta1.Query",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: "Pool", Handler: unaryHandler((*testGovServer).pool)},
+		},
+	}, &testGovServer{t: t})
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "cosmos.authz.v1beta1.Query",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: "Grants", Handler: unaryHandler((*testGovServer).grants)},
+		},
+	}, &testGovServer{t: t})
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "cosmos.bank.v1beta1.Query",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: "Balance", Handler: unaryHandler((*testGovServer).balance)},
+		},
+	}, &testGovServer{t: t})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	querier, err := newGrpcQuerier("bufnet", false, grpc.WithContextDialer(
+		func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		},
+	))
+	require.NoError(t, err)
+	t.Cleanup(func() { querier.conn.Close() })
+	return querier
+}
+
+func TestGrpcGetProposals(t *testing.T) {
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "", "")
+	voter.querier = newTestGrpcQuerier(t)
+	proposals, err := voter.GetVoting(context.Background())
+	require.NoError(t, err)
+	require.Len(t, proposals, 2)
+
+	assert.Equal(t, "291", proposals[0].Id)
+	assert.Equal(t, "GHOST: Instantiate ATOM Vault", proposals[0].Title)
+	assert.Equal(t, "This will deploy a GHOST Vault for ATOM", proposals[0].Description)
+	assert.Equal(t, testGrpcVotingEnd, proposals[0].VotingEndTime)
+	assert.Equal(t, 89.2, proposals[0].VotedYes)
+	assert.Equal(t, 42.3, proposals[0].Voted)
+	assert.Equal(t, 40.0, proposals[0].Quorum)
+	assert.Equal(t, "kujira1proposer", proposals[0].Proposer)
+	assert.Equal(t, []Coin{{Denom: "ukuji", Amount: "10000000000"}}, proposals[0].Deposit)
+	assert.Equal(t, []ProposalMessage{{
+		Type:        grpcExecLegacyContentType,
+		ContentType: "/cosmwasm.wasm.v1.InstantiateContractProposal",
+		Authority:   "kujira1gov",
+		Admin:       "kujira1admin",
+		CodeID:      "106",
+	}}, proposals[0].Messages)
+	assert.True(t, proposals[0].WouldPass)
+
+	assert.Equal(t, "294", proposals[1].Id)
+	assert.Equal(t, "Upgrade v0.8.4", proposals[1].Title)
+	assert.Equal(t, "Upgrade kaiyo-1 to v0.8.4", proposals[1].Description)
+}
+
+func TestGrpcHasVoted(t *testing.T) {
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "", "")
+	voter.querier = newTestGrpcQuerier(t)
+	voted, err := voter.HasVoted(context.Background(), "295")
+	assert.NoError(t, err)
+	assert.True(t, voted)
+	voted, err = voter.HasVoted(context.Background(), "291")
+	assert.NoError(t, err)
+	assert.False(t, voted)
+	// other invalid arguments are errors, not missing votes
+	_, err = voter.HasVoted(context.Background(), "0")
+	assert.ErrorContains(t, err, "proposal id can not be 0")
+}
+
+func TestGrpcCheckGrant(t *testing.T) {
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "", "")
+	voter.querier = newTestGrpcQuerier(t)
+	voter.UseAuthz("granterWallet")
+	grant, err := voter.CheckGrant(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, grant.Expiration)
+	assert.Equal(t, testGrpcGrantExpiration, *grant.Expiration)
+
+	voter.UseAuthz("otherWallet")
+	_, err = voter.CheckGrant(context.Background())
+	assert.Error(t, err)
+}
+
+func TestGrpcBalance(t *testing.T) {
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "250ukuji", "kaiyo-1")
+	voter.querier = newTestGrpcQuerier(t)
+	balance, err := voter.Balance(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "12480ukuji, enough for 49 votes", balance.String())
+
+	voter = NewCosmosVoter("daemon", "password", "voterWallet", "250uatom", "cosmoshub-4")
+	voter.querier = newTestGrpcQuerier(t)
+	balance, err = voter.Balance(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), balance.Votes())
+}
@@ -0,0 +1,179 @@
+package vote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	lcdProposalsPath   = "/cosmos/gov/v1/proposals"
+	lcdVotePath        = "/cosmos/gov/v1/proposals/%s/votes/%s"
+	lcdTallyPath       = "/cosmos/gov/v1/proposals/%s/tally"
+	lcdStakingPoolPath = "/cosmos/staking/v1beta1/pool"
+	lcdTallyParamsPath = "/cosmos/gov/v1/params/tallying"
+	lcdAuthzGrantsPath = "/cosmos/authz/v1beta1/grants"
+	lcdBalancePath     = "/cosmos/bank/v1beta1/balances/%s/by_denom"
+
+	lcdVotingPeriodStatus = "PROPOSAL_STATUS_VOTING_PERIOD"
+	// grpc NotFound status code
+	lcdNotFoundCode = 5
+	lcdTimeout      = time.Second * 10
+)
+
+var (
+	errLcdNotFound = fmt.Errorf("not found")
+)
+
+type lcdErrorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lcdVoteResponse struct {
+	Vote cosmosHasVotedResponse `json:"vote"`
+}
+
+type lcdTallyResponse struct {
+	Tally cosmosTallyResponse `json:"tally"`
+}
+
+// lcdQuerier runs queries against LCD REST endpoint of a node
+type lcdQuerier struct {
+	url    string
+	client *http.Client
+}
+
+func newLcdQuerier(lcdURL string) *lcdQuerier {
+	return &lcdQuerier{
+		url:    strings.TrimSuffix(lcdURL, "/"),
+		client: &http.Client{Timeout: lcdTimeout},
+	}
+}
+
+func (q *lcdQuerier) proposals(ctx context.Context) ([]cosmosProposal, error) {
+	return queryAllPages(func(pageKey string) ([]cosmosProposal, string, error) {
+		query := url.Values{
+			"proposal_status":  []string{lcdVotingPeriodStatus},
+			"pagination.limit": []string{strconv.Itoa(pageLimit)},
+		}
+		if pageKey != "" {
+			query.Set("pagination.key", pageKey)
+		}
+		cosmosProposals := cosmosProposalsResponse{}
+		if err := q.get(ctx, lcdProposalsPath, query, &cosmosProposals); err != nil {
+			return nil, "", fmt.Errorf("failed to query cosmos proposals: %v", err)
+		}
+		return cosmosProposals.Proposals, cosmosProposals.Pagination.nextKey(), nil
+	})
+}
+
+func (q *lcdQuerier) vote(ctx context.Context, id string, voter string) (*cosmosHasVotedResponse, error) {
+	path := fmt.Sprintf(lcdVotePath, url.PathEscape(id), url.PathEscape(voter))
+	hasVoted := lcdVoteResponse{}
+	if err := q.get(ctx, path, nil, &hasVoted); err != nil {
+		if errors.Is(err, errLcdNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query vote: %v", err)
+	}
+	return &hasVoted.Vote, nil
+}
+
+func (q *lcdQuerier) tally(ctx context.Context, id string) (*cosmosTallyResponse, error) {
+	tally := lcdTallyResponse{}
+	if err := q.get(ctx, fmt.Sprintf(lcdTallyPath, url.PathEscape(id)), nil, &tally); err != nil {
+		return nil, fmt.Errorf("failed to query tally: %v", err)
+	}
+	return &tally.Tally, nil
+}
+
+func (q *lcdQuerier) bondedTokens(ctx context.Context) (float64, error) {
+	pool := &cosmosStakingPoolResponse{}
+	if err := q.get(ctx, lcdStakingPoolPath, nil, pool); err != nil {
+		return 0, fmt.Errorf("failed to query staking pool: %v", err)
+	}
+	return pool.bondedTokens()
+}
+
+func (q *lcdQuerier) tallyParams(ctx context.Context) (*cosmosTallyParams, error) {
+	params := &cosmosGovParamsResponse{}
+	if err := q.get(ctx, lcdTallyParamsPath, nil, params); err != nil {
+		return nil, fmt.Errorf("failed to query gov params: %v", err)
+	}
+	return params.tallyParams()
+}
+
+func (q *lcdQuerier) grants(ctx context.Context, granter string, grantee string, msgType string) ([]cosmosGrant, error) {
+	query := url.Values{
+		"granter":      []string{granter},
+		"grantee":      []string{grantee},
+		"msg_type_url": []string{msgType},
+	}
+	grants := cosmosGrantsResponse{}
+	if err := q.get(ctx, lcdAuthzGrantsPath, query, &grants); err != nil {
+		if errors.Is(err, errLcdNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query authz grants: %v", err)
+	}
+	return grants.Grants, nil
+}
+
+type lcdBalanceResponse struct {
+	Balance *cosmosCoin `json:"balance"`
+}
+
+func (q *lcdQuerier) balance(ctx context.Context, address string, denom string) (string, error) {
+	resp := &lcdBalanceResponse{}
+	query := url.Values{"denom": []string{denom}}
+	if err := q.get(ctx, fmt.Sprintf(lcdBalancePath, address), query, resp); err != nil {
+		return "", fmt.Errorf("failed to query balance: %v", err)
+	}
+	if resp.Balance == nil || resp.Balance.Amount == "" {
+		return "0", nil
+	}
+	return resp.Balance.Amount, nil
+}
+
+func (q *lcdQuerier) get(ctx context.Context, path string, query url.Values, resp interface{}) error {
+	reqURL := q.url + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return err
+	}
+	res, err := q.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %v", path, err)
+	}
+	if res.StatusCode != http.StatusOK {
+		lcdErr := lcdErrorResponse{}
+		_ = json.Unmarshal(body, &lcdErr)
+		// gov module reports missing votes as InvalidArgument, so check the message too
+		if res.StatusCode == http.StatusNotFound ||
+			lcdErr.Code == lcdNotFoundCode ||
+			strings.Contains(lcdErr.Message, "not found") {
+			return fmt.Errorf("%s: %w", path, errLcdNotFound)
+		}
+		return fmt.Errorf("%s responded with status %d: %s", path, res.StatusCode, lcdErr.Message)
+	}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return fmt.Errorf("failed to unmarshal %s response: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package vote
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLcdServer(t *testing.T) *httptest.Server {
+	notFound := []byte(`{"code": 3, "message": "voter: voterWallet not found for proposal: 294", "details": []}`)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cosmos/gov/v1/proposals", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PROPOSAL_STATUS_VOTING_PERIOD", r.URL.Query().Get("proposal_status"))
+		w.Write(example_proposals)
+	})
+	mux.HandleFunc("/cosmos/gov/v1/proposals/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cosmos/gov/v1/proposals/291/votes/voterWallet":
+			fmt.Fprintf(w, `{"vote": %s}`, example_vote_291)
+		case "/cosmos/gov/v1/proposals/294/votes/voterWallet",
+			"/cosmos/gov/v1/proposals/295/votes/voterWallet":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(notFound)
+		case "/cosmos/gov/v1/proposals/294/tally",
+			"/cosmos/gov/v1/proposals/295/tally":
+			fmt.Fprintf(w, `{"tally": %s}`, example_tally)
+		default:
+			t.Errorf("unexpected lcd request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	})
+	mux.HandleFunc("/cosmos/staking/v1beta1/pool", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"pool": %s}`, example_staking_pool)
+	})
+	mux.HandleFunc("/cosmos/gov/v1/params/tallying", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(example_gov_params)
+	})
+	mux.HandleFunc("/cosmos/authz/v1beta1/grants", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "voterWallet", r.URL.Query().Get("grantee"))
+		assert.Contains(t, voteMsgTypeURLs, r.URL.Query().Get("msg_type_url"))
+		if r.URL.Query().Get("granter") != "granterWallet" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code": 5, "message": "no authorization found", "details": []}`))
+			return
+		}
+		w.Write(example_authz_grants)
+	})
+	mux.HandleFunc("/cosmos/bank/v1beta1/balances/voterWallet/by_denom", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"balance": {"denom": "%s", "amount": "12480"}}`, r.URL.Query().Get("denom"))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestLcdGetProposals(t *testing.T) {
+	server := newTestLcdServer(t)
+	defer server.Close()
+
+	voter := NewCosmosLcdVoter(server.URL, "daemon", "password", "voterWallet", "", "")
+	proposals, err := voter.GetVoting(context.Background())
+	require.NoError(t, err)
+	require.Len(t, proposals, 2)
+	assert.Equal(t, "294", proposals[0].Id)
+	assert.Equal(t, "295", proposals[1].Id)
+	assert.NotEmpty(t, proposals[0].Title)
+	assert.Equal(t, 89.2, proposals[0].VotedYes)
+	assert.Equal(t, 42.3, proposals[0].Voted)
+	assert.Equal(t, 33.4, proposals[0].Quorum)
+	assert.True(t, proposals[0].WouldPass)
+}
+
+func TestLcdHasVoted(t *testing.T) {
+	server := newTestLcdServer(t)
+	defer server.Close()
+
+	voter := NewCosmosLcdVoter(server.URL, "daemon", "password", "voterWallet", "", "")
+	voted, err := voter.HasVoted(context.Background(), "291")
+	assert.NoError(t, err)
+	assert.True(t, voted)
+	voted, err = voter.HasVoted(context.Background(), "294")
+	assert.NoError(t, err)
+	assert.False(t, voted)
+}
+
+func TestLcdQueryFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"code": 13, "message": "internal"}`))
+	}))
+	defer server.Close()
+
+	voter := NewCosmosLcdVoter(server.URL, "daemon", "password", "voterWallet", "", "")
+	_, err := voter.GetVoting(context.Background())
+	assert.Error(t, err)
+	voted, err := voter.HasVoted(context.Background(), "291")
+	assert.Error(t, err)
+	assert.False(t, voted)
+}
+
+func TestLcdCheckGrant(t *testing.T) {
+	server := newTestLcdServer(t)
+	defer server.Close()
+
+	voter := NewCosmosLcdVoter(server.URL, "daemon", "password", "voterWallet", "", "")
+	voter.UseAuthz("granterWallet")
+	grant, err := voter.CheckGrant(context.Background())
+	require.NoError(t, err)
+	assert.NotNil(t, grant.Expiration)
+
+	voter.UseAuthz("otherWallet")
+	_, err = voter.CheckGrant(context.Background())
+	assert.Error(t, err)
+}
+
+func TestLcdBalance(t *testing.T) {
+	server := newTestLcdServer(t)
+	defer server.Close()
+
+	voter := NewCosmosLcdVoter(server.URL, "daemon", "password", "voterWallet", "250ukuji", "kaiyo-1")
+	balance, err := voter.Balance(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "12480ukuji, enough for 49 votes", balance.String())
+}
@@ -0,0 +1,41 @@
+package vote
+
+import "fmt"
+
+const (
+	// page size requested from the node, sdk default is 100
+	pageLimit = 100
+)
+
+type cosmosPagination struct {
+	// base64 key of the next page, null on the last one
+	NextKey *string `json:"next_key"`
+}
+
+func (p *cosmosPagination) nextKey() string {
+	if p == nil || p.NextKey == nil {
+		return ""
+	}
+	return *p.NextKey
+}
+
+// queryAllPages calls fetchPage starting with an empty page key and then with
+// the next key returned by the previous page until the last page is reached
+func queryAllPages[T any](fetchPage func(pageKey string) ([]T, string, error)) ([]T, error) {
+	var all []T
+	pageKey := ""
+	for {
+		items, nextKey, err := fetchPage(pageKey)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if nextKey == "" {
+			return all, nil
+		}
+		if nextKey == pageKey {
+			return nil, fmt.Errorf("pagination is stuck at page key '%s'", pageKey)
+		}
+		pageKey = nextKey
+	}
+}
@@ -0,0 +1,293 @@
+package vote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	cosmosQueryTxCmdArgs = "query tx %s -o json"
+	cosmosBroadcastFlags = "-y -o json"
+)
+
+var (
+	// 'tx -y' returns once tx is in mempool, inclusion is polled for afterwards
+	txPollInterval   = time.Second * 2
+	txConfirmTimeout = time.Minute
+	// recoverable tx errors are retried with doubling backoff
+	txRetries      = 3
+	txRetryBackoff = time.Second * 3
+
+	ErrTxNotConfirmed = fmt.Errorf("tx is not included in a block in time")
+)
+
+// VoteResult describes the vote transaction included in a block
+type VoteResult struct {
+	TxHash  string
+	Height  int64
+	GasUsed int64
+	// fee paid like 250ukuji
+	Fee string
+}
+
+func (r *VoteResult) String() string {
+	return fmt.Sprintf("tx %s included at height %d, gas used %d, fee %s", r.TxHash, r.Height, r.GasUsed, r.Fee)
+}
+
+type TxErrorClass int
+
+const (
+	TxErrorUnknown TxErrorClass = iota
+	TxErrorSequenceMismatch
+	TxErrorInsufficientFees
+	TxErrorOutOfGas
+	TxErrorMempoolFull
+)
+
+const (
+	sdkCodespace = "sdk"
+)
+
+var (
+	// sdk error codes and messages, messages are matched in cli stderr which has no code
+	txErrorClasses = []struct {
+		class   TxErrorClass
+		code    uint32
+		message string
+		hint    string
+	}{
+		{TxErrorSequenceMismatch, 32, "account sequence mismatch", "another tx of the wallet was in flight, try again"},
+		{TxErrorInsufficientFees, 13, "insufficient fee", "raise fees of the chain in config"},
+		{TxErrorOutOfGas, 11, "out of gas", "raise gas limit of the chain"},
+		{TxErrorMempoolFull, 20, "mempool is full", "node is congested, try again later"},
+	}
+)
+
+// classifyTxError recognizes sdk errors by code, or by message if code is unknown
+func classifyTxError(codespace string, code uint32, rawLog string) TxErrorClass {
+	for _, known := range txErrorClasses {
+		if codespace == sdkCodespace && code == known.code {
+			return known.class
+		}
+	}
+	for _, known := range txErrorClasses {
+		if strings.Contains(rawLog, known.message) {
+			return known.class
+		}
+	}
+	return TxErrorUnknown
+}
+
+func (c TxErrorClass) String() string {
+	for _, known := range txErrorClasses {
+		if known.class == c {
+			return known.message
+		}
+	}
+	return "unknown error"
+}
+
+// Hint tells how to get the tx through
+func (c TxErrorClass) Hint() string {
+	for _, known := range txErrorClasses {
+		if known.class == c {
+			return known.hint
+		}
+	}
+	return "see bot logs"
+}
+
+// Recoverable errors may go away on retry, the rest need config changes
+func (c TxErrorClass) Recoverable() bool {
+	return c == TxErrorSequenceMismatch || c == TxErrorMempoolFull
+}
+
+// TxError is a transaction rejected on broadcast or failed on execution
+type TxError struct {
+	// empty if tx command failed before broadcast
+	TxHash    string
+	Codespace string
+	Code      uint32
+	RawLog    string
+	Class     TxErrorClass
+}
+
+func (e *TxError) Error() string {
+	if e.TxHash == "" {
+		return fmt.Sprintf("tx failed with %s: %s", e.Class, e.RawLog)
+	}
+	return fmt.Sprintf("tx %s failed with %s, code %d (%s): %s", e.TxHash, e.Class, e.Code, e.Codespace, e.RawLog)
+}
+
+type cosmosTxResponse struct {
+	Height    string    `json:"height"`
+	TxHash    string    `json:"txhash"`
+	Codespace string    `json:"codespace"`
+	Code      uint32    `json:"code"`
+	RawLog    string    `json:"raw_log"`
+	GasUsed   string    `json:"gas_used"`
+	Tx        *cosmosTx `json:"tx"`
+}
+
+type cosmosTx struct {
+	AuthInfo struct {
+		Fee struct {
+			Amount []cosmosCoin `json:"amount"`
+		} `json:"fee"`
+	} `json:"auth_info"`
+}
+
+func (r *cosmosTxResponse) err() error {
+	if r.Code == 0 {
+		return nil
+	}
+	return &TxError{
+		TxHash:    r.TxHash,
+		Codespace: r.Codespace,
+		Code:      r.Code,
+		RawLog:    r.RawLog,
+		Class:     classifyTxError(r.Codespace, r.Code, r.RawLog),
+	}
+}
+
+func (r *cosmosTxResponse) result() (*VoteResult, error) {
+	height, err := strconv.ParseInt(r.Height, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("tx height '%s' is not integer", r.Height)
+	}
+	gasUsed, err := strconv.ParseInt(r.GasUsed, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("tx gas used '%s' is not integer", r.GasUsed)
+	}
+	fee := []string{}
+	if r.Tx != nil {
+		for _, coin := range r.Tx.AuthInfo.Fee.Amount {
+			fee = append(fee, coin.Amount+coin.Denom)
+		}
+	}
+	return &VoteResult{
+		TxHash:  r.TxHash,
+		Height:  height,
+		GasUsed: gasUsed,
+		Fee:     strings.Join(fee, ","),
+	}, nil
+}
+
+// parseTxResponse skips anything printed before json, like gas estimate
+func parseTxResponse(stdout []byte) (*cosmosTxResponse, error) {
+	start := bytes.IndexByte(stdout, '{')
+	if start < 0 {
+		return nil, fmt.Errorf("no json in tx response")
+	}
+	resp := &cosmosTxResponse{}
+	if err := json.Unmarshal(stdout[start:], resp); err != nil {
+		return nil, err
+	}
+	if resp.TxHash == "" {
+		return nil, fmt.Errorf("tx response has no hash")
+	}
+	return resp, nil
+}
+
+// broadcast sends tx of voter wallet after its previous txs are included,
+// recoverable failures are retried
+func (cv *CosmosVoter) broadcast(ctx context.Context, args []string, input []byte, txName string) (*VoteResult, error) {
+	queue := walletTxQueue(cv.chainId, cv.voterWallet)
+	if err := queue.acquire(ctx); err != nil {
+		return nil, fmt.Errorf("%s tx is not sent while waiting for previous tx of %s: %v", txName, cv.voterWallet, err)
+	}
+	defer queue.release()
+
+	backoff := txRetryBackoff
+	for attempt := 1; ; attempt++ {
+		result, err := cv.broadcastOnce(ctx, args, input, txName)
+		txErr := &TxError{}
+		if err == nil || attempt > txRetries || !errors.As(err, &txErr) || !txErr.Class.Recoverable() {
+			return result, err
+		}
+		log.Warnf("%s tx attempt %d failed, retry in %s: %v", txName, attempt, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
+
+// broadcastOnce runs tx command with json output and waits for the tx to be included
+func (cv *CosmosVoter) broadcastOnce(ctx context.Context, args []string, input []byte, txName string) (*VoteResult, error) {
+	args = append(append([]string{}, args...), strings.Fields(cosmosBroadcastFlags)...)
+	runner := defRunnerFactory()
+	stdout, stderr, err := runner.Run(ctx, cv.daemonPath, args, input)
+	if err != nil {
+		logCmdErr(cv.daemonPath, args, stdout, stderr, err)
+		// sdk cli reports some broadcast errors with non-zero exit and plain text
+		if class := classifyTxError("", 0, string(stderr)); class != TxErrorUnknown {
+			txErr := &TxError{RawLog: strings.TrimSpace(string(stderr)), Class: class}
+			return nil, fmt.Errorf("failed to run %s tx: %w", txName, txErr)
+		}
+		return nil, fmt.Errorf("failed to run %s tx: %v", txName, err)
+	}
+	log.Infof("%s tx:\n%s", txName, string(stdout))
+	resp, err := parseTxResponse(stdout)
+	if err != nil {
+		logCmdErr(cv.daemonPath, args, stdout, stderr, err)
+		return nil, fmt.Errorf("failed to parse %s tx response: %v", txName, err)
+	}
+	if err := resp.err(); err != nil {
+		return nil, fmt.Errorf("%s tx rejected: %w", txName, err)
+	}
+	result, err := cv.waitTx(ctx, resp.TxHash)
+	if err != nil {
+		return nil, fmt.Errorf("%s tx failed: %w", txName, err)
+	}
+	return result, nil
+}
+
+// waitTx polls the tx until it is found in a block
+func (cv *CosmosVoter) waitTx(ctx context.Context, hash string) (*VoteResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, txConfirmTimeout)
+	defer cancel()
+	ticker := time.NewTicker(txPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("tx %s: %w", hash, ErrTxNotConfirmed)
+		}
+		resp, err := cv.queryTx(ctx, hash)
+		if err != nil {
+			// query fails until tx is included
+			log.Debugf("tx %s is not found yet: %v", hash, err)
+			continue
+		}
+		if err := resp.err(); err != nil {
+			return nil, err
+		}
+		return resp.result()
+	}
+}
+
+func (cv *CosmosVoter) queryTx(ctx context.Context, hash string) (*cosmosTxResponse, error) {
+	args := strings.Fields(fmt.Sprintf(cosmosQueryTxCmdArgs, hash))
+	runner := defRunnerFactory()
+	stdout, stderr, err := runner.Run(ctx, cv.daemonPath, args, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run tx query: %v: %s", err, string(stderr))
+	}
+	resp, err := parseTxResponse(stdout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tx query response: %v", err)
+	}
+	return resp, nil
+}
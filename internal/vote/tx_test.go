@@ -0,0 +1,163 @@
+package vote
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/kostage/cosmos_voter/internal/cmdrunner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCosmosVoteRejected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), []byte("password")).Return(example_tx_broadcast_rejected, nil, nil)
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "25ukuji", "kaiyo-1")
+	_, err := voter.Vote(context.Background(), "1", VoteOptionYes)
+	txErr := &TxError{}
+	require.ErrorAs(t, err, &txErr)
+	assert.Equal(t, uint32(13), txErr.Code)
+	assert.Equal(t, "sdk", txErr.Codespace)
+	assert.Contains(t, txErr.RawLog, "insufficient fee")
+}
+
+func TestCosmosVoteFailedInBlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	txPollInterval = time.Millisecond
+	failed := []byte(fmt.Sprintf(
+		`{"height": "14532761", "txhash": "%s", "codespace": "sdk", "code": 11, "raw_log": "out of gas", "gas_used": "200100"}`,
+		exampleTxHash))
+	queryArgs := []string{"query", "tx", exampleTxHash, "-o", "json"}
+	gomock.InOrder(
+		runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), []byte("password")).Return(example_tx_broadcast, nil, nil),
+		// not indexed yet
+		runner.EXPECT().Run(gomock.Any(), "daemon", queryArgs, nil).Return(nil, []byte("tx not found"), fmt.Errorf("exit status 1")),
+		runner.EXPECT().Run(gomock.Any(), "daemon", queryArgs, nil).Return(failed, nil, nil),
+	)
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "250ukuji", "kaiyo-1")
+	_, err := voter.Vote(context.Background(), "1", VoteOptionYes)
+	txErr := &TxError{}
+	require.ErrorAs(t, err, &txErr)
+	assert.Equal(t, uint32(11), txErr.Code)
+}
+
+func TestCosmosVoteNotConfirmed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	txPollInterval = time.Millisecond
+	defer func(timeout time.Duration) { txConfirmTimeout = timeout }(txConfirmTimeout)
+	txConfirmTimeout = time.Millisecond * 50
+	runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), []byte("password")).Return(example_tx_broadcast, nil, nil)
+	runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), nil).Return(nil, []byte("tx not found"), fmt.Errorf("exit status 1")).AnyTimes()
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "250ukuji", "kaiyo-1")
+	_, err := voter.Vote(context.Background(), "1", VoteOptionYes)
+	assert.ErrorIs(t, err, ErrTxNotConfirmed)
+}
+
+func TestParseTxResponse(t *testing.T) {
+	resp, err := parseTxResponse(append([]byte("gas estimate: 84213\n"), example_tx_broadcast...))
+	require.NoError(t, err)
+	assert.Equal(t, exampleTxHash, resp.TxHash)
+
+	_, err = parseTxResponse([]byte("Error: key not found"))
+	assert.Error(t, err)
+	_, err = parseTxResponse([]byte(`{"code": 0}`))
+	assert.Error(t, err)
+}
+
+func TestCosmosVoteRetried(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	txRetryBackoff = time.Millisecond
+	mismatch := []byte("Error: account sequence mismatch, expected 5, got 4: incorrect account sequence")
+	mempoolFull := []byte(fmt.Sprintf(
+		`{"height": "0", "txhash": "%s", "codespace": "sdk", "code": 20, "raw_log": "mempool is full"}`, exampleTxHash))
+	gomock.InOrder(
+		runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), []byte("password")).Return(nil, mismatch, fmt.Errorf("exit status 1")),
+		runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), []byte("password")).Return(mempoolFull, nil, nil),
+		runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), []byte("password")).Return(example_tx_broadcast, nil, nil),
+		expectTxIncluded(runner),
+	)
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "250ukuji", "kaiyo-1")
+	result, err := voter.Vote(context.Background(), "1", VoteOptionYes)
+	require.NoError(t, err)
+	assert.Equal(t, exampleTxHash, result.TxHash)
+}
+
+func TestCosmosVoteRetriesExhausted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	txRetryBackoff = time.Millisecond
+	mismatch := []byte("Error: account sequence mismatch, expected 5, got 4: incorrect account sequence")
+	runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), []byte("password")).
+		Return(nil, mismatch, fmt.Errorf("exit status 1")).Times(txRetries + 1)
+
+	voter := NewCosmosVoter("daemon", "password", "voterWallet", "250ukuji", "kaiyo-1")
+	_, err := voter.Vote(context.Background(), "1", VoteOptionYes)
+	txErr := &TxError{}
+	require.ErrorAs(t, err, &txErr)
+	assert.Equal(t, TxErrorSequenceMismatch, txErr.Class)
+}
+
+func TestCosmosVotesSerialized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	runner := cmdrunner.NewMockCmdRunner(ctrl)
+	defRunnerFactory = func() cmdrunner.CmdRunner { return runner }
+	txPollInterval = time.Millisecond
+	inFlight := int32(0)
+	broadcast := func(context.Context, string, []string, []byte) ([]byte, []byte, error) {
+		assert.Equal(t, int32(1), atomic.AddInt32(&inFlight, 1), "tx is sent before previous one is included")
+		return example_tx_broadcast, nil, nil
+	}
+	included := func(context.Context, string, []string, []byte) ([]byte, []byte, error) {
+		time.Sleep(time.Millisecond * 10)
+		atomic.AddInt32(&inFlight, -1)
+		return example_tx, nil, nil
+	}
+	queryArgs := []string{"query", "tx", exampleTxHash, "-o", "json"}
+	runner.EXPECT().Run(gomock.Any(), "daemon", gomock.Any(), []byte("password")).DoAndReturn(broadcast).Times(2)
+	runner.EXPECT().Run(gomock.Any(), "daemon", queryArgs, nil).DoAndReturn(included).Times(2)
+
+	// voters of the same chain and wallet
+	voters := []*CosmosVoter{
+		NewCosmosVoter("daemon", "password", "voterWallet", "250ukuji", "kaiyo-1"),
+		NewCosmosVoter("daemon", "password", "voterWallet", "250ukuji", "kaiyo-1"),
+	}
+	wg := sync.WaitGroup{}
+	for _, voter := range voters {
+		wg.Add(1)
+		go func(voter *CosmosVoter) {
+			defer wg.Done()
+			_, err := voter.Vote(context.Background(), "1", VoteOptionYes)
+			assert.NoError(t, err)
+		}(voter)
+	}
+	wg.Wait()
+}
+
+func TestClassifyTxError(t *testing.T) {
+	assert.Equal(t, TxErrorSequenceMismatch, classifyTxError("sdk", 32, ""))
+	assert.Equal(t, TxErrorInsufficientFees, classifyTxError("sdk", 13, ""))
+	assert.Equal(t, TxErrorOutOfGas, classifyTxError("", 0, "out of gas in location: WriteFlat; gasWanted: 200000, gasUsed: 200100"))
+	assert.Equal(t, TxErrorMempoolFull, classifyTxError("", 0, "mempool is full: number of txs 5000"))
+	// codes of other modules mean other errors
+	assert.Equal(t, TxErrorUnknown, classifyTxError("gov", 13, "inactive proposal"))
+	assert.False(t, TxErrorInsufficientFees.Recoverable())
+	assert.True(t, TxErrorSequenceMismatch.Recoverable())
+}
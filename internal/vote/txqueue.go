@@ -0,0 +1,42 @@
+package vote
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	txQueuesMtx sync.Mutex
+	// by chain id and wallet, voters of the same wallet share the queue
+	txQueues = map[string]txQueue{}
+)
+
+// txQueue lets one transaction of a wallet at a time, the next one is signed
+// only after the previous is included, otherwise it gets the same sequence
+type txQueue chan struct{}
+
+func walletTxQueue(chainID string, wallet string) txQueue {
+	txQueuesMtx.Lock()
+	defer txQueuesMtx.Unlock()
+	key := chainID + "/" + wallet
+	queue, ok := txQueues[key]
+	if !ok {
+		queue = make(txQueue, 1)
+		txQueues[key] = queue
+	}
+	return queue
+}
+
+// acquire waits for the turn of the caller, release must follow on success
+func (q txQueue) acquire(ctx context.Context) error {
+	select {
+	case q <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q txQueue) release() {
+	<-q
+}
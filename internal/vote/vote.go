@@ -2,22 +2,198 @@ package vote
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 )
 
+type VoteOption int
+
+const (
+	VoteOptionUnspecified VoteOption = iota
+	VoteOptionYes
+	VoteOptionAbstain
+	VoteOptionNo
+	VoteOptionNoWithVeto
+)
+
+const (
+	weightSumTolerance = 1e-9
+)
+
+var (
+	voteOptionNames = map[VoteOption]string{
+		VoteOptionYes:        "yes",
+		VoteOptionAbstain:    "abstain",
+		VoteOptionNo:         "no",
+		VoteOptionNoWithVeto: "no_with_veto",
+	}
+	voteOptionCosmosNames = map[VoteOption]string{
+		VoteOptionYes:        "VOTE_OPTION_YES",
+		VoteOptionAbstain:    "VOTE_OPTION_ABSTAIN",
+		VoteOptionNo:         "VOTE_OPTION_NO",
+		VoteOptionNoWithVeto: "VOTE_OPTION_NO_WITH_VETO",
+	}
+)
+
+// String returns option as accepted by 'tx gov vote' command
+func (o VoteOption) String() string {
+	if name, ok := voteOptionNames[o]; ok {
+		return name
+	}
+	return "unspecified"
+}
+
+// CosmosString returns option as reported by gov queries (VOTE_OPTION_*)
+func (o VoteOption) CosmosString() string {
+	if name, ok := voteOptionCosmosNames[o]; ok {
+		return name
+	}
+	return "VOTE_OPTION_UNSPECIFIED"
+}
+
+// ParseVoteOption accepts both cli (yes, no_with_veto) and query (VOTE_OPTION_YES) forms
+func ParseVoteOption(s string) (VoteOption, error) {
+	for opt, name := range voteOptionNames {
+		if s == name || s == voteOptionCosmosNames[opt] {
+			return opt, nil
+		}
+	}
+	return VoteOptionUnspecified, fmt.Errorf("unknown vote option '%s'", s)
+}
+
+// WeightedVoteOption is one part of a split vote, weight is in (0, 1]
+type WeightedVoteOption struct {
+	Option VoteOption
+	Weight float64
+}
+
+type WeightedVoteOptions []WeightedVoteOption
+
+// String returns options as accepted by 'tx gov weighted-vote' command: yes=0.7,abstain=0.3
+func (opts WeightedVoteOptions) String() string {
+	parts := make([]string, 0, len(opts))
+	for _, opt := range opts {
+		parts = append(parts, fmt.Sprintf("%s=%s", opt.Option, strconv.FormatFloat(opt.Weight, 'f', -1, 64)))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Validate checks options are unique, weights are positive and sum to 1
+func (opts WeightedVoteOptions) Validate() error {
+	if len(opts) == 0 {
+		return fmt.Errorf("no vote options")
+	}
+	seen := make(map[VoteOption]bool, len(opts))
+	sum := 0.0
+	for _, opt := range opts {
+		if _, ok := voteOptionNames[opt.Option]; !ok {
+			return fmt.Errorf("unknown vote option %d", opt.Option)
+		}
+		if seen[opt.Option] {
+			return fmt.Errorf("duplicate vote option '%s'", opt.Option)
+		}
+		seen[opt.Option] = true
+		if opt.Weight <= 0 || opt.Weight > 1 {
+			return fmt.Errorf("weight of '%s' is not in (0, 1]", opt.Option)
+		}
+		sum += opt.Weight
+	}
+	if math.Abs(sum-1) > weightSumTolerance {
+		return fmt.Errorf("weights sum to %v, not 1", sum)
+	}
+	return nil
+}
+
+// ParseWeightedVoteOptions parses and validates options like yes=0.7,abstain=0.3
+func ParseWeightedVoteOptions(s string) (WeightedVoteOptions, error) {
+	opts := WeightedVoteOptions{}
+	for _, part := range strings.Split(s, ",") {
+		optStr, weightStr, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			return nil, fmt.Errorf("option '%s' is not in option=weight form", part)
+		}
+		opt, err := ParseVoteOption(optStr)
+		if err != nil {
+			return nil, err
+		}
+		weight, err := strconv.ParseFloat(weightStr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("weight of '%s' is not a number: %v", optStr, err)
+		}
+		opts = append(opts, WeightedVoteOption{Option: opt, Weight: weight})
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// ProposalMessage describes a message executed by a proposal
+type ProposalMessage struct {
+	// message @type
+	Type string
+	// @type of legacy content executed by MsgExecLegacyContent
+	ContentType string
+	Authority   string
+	// admin and code id of wasm proposals
+	Admin  string
+	CodeID string
+}
+
+type Coin struct {
+	Denom  string
+	Amount string
+}
+
 type Proposal struct {
 	Id          string
 	Title       string
 	Description string
+	// proposer is reported since sdk v0.47
+	Proposer    string
+	Messages    []ProposalMessage
+	Deposit     []Coin
 	VotedYes    float64
 	VotedNo     float64
 	Veto        float64
 	DeadlineHrs float64
 	Voted       float64
+	// gov tally params, percents
+	Quorum        float64
+	Threshold     float64
+	VetoThreshold float64
+	// proposal would pass if voting ended with the current tally
+	WouldPass bool
+
+	VotingEndTime time.Time
+}
+
+// Tally is the current tally of a proposal in percents
+type Tally struct {
+	VotedYes float64
+	VotedNo  float64
+	Veto     float64
+	// turnout of bonded tokens
+	Voted float64
+	// gov tally params
+	Quorum        float64
+	Threshold     float64
+	VetoThreshold float64
+	// proposal would pass if voting ended with the current tally
+	WouldPass bool
 }
 
 //go:generate mockgen -source vote.go -destination vote_mock.go -package vote
 type Voter interface {
+	// GetVoting returns proposals in voting period the voter has not voted on
 	GetVoting(context.Context) ([]Proposal, error)
 	HasVoted(context.Context, string) (bool, error)
-	Vote(context.Context, string, string) error
+	// Tally returns tally of proposal whether voted or not
+	Tally(context.Context, string) (*Tally, error)
+	// Vote returns once the vote tx is included in a block
+	Vote(context.Context, string, VoteOption) (*VoteResult, error)
+	WeightedVote(context.Context, string, WeightedVoteOptions) (*VoteResult, error)
 }
@@ -64,12 +64,28 @@ func (mr *MockVoterMockRecorder) HasVoted(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasVoted", reflect.TypeOf((*MockVoter)(nil).HasVoted), arg0, arg1)
 }
 
+// Tally mocks base method.
+func (m *MockVoter) Tally(arg0 context.Context, arg1 string) (*Tally, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Tally", arg0, arg1)
+	ret0, _ := ret[0].(*Tally)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Tally indicates an expected call of Tally.
+func (mr *MockVoterMockRecorder) Tally(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tally", reflect.TypeOf((*MockVoter)(nil).Tally), arg0, arg1)
+}
+
 // Vote mocks base method.
-func (m *MockVoter) Vote(arg0 context.Context, arg1 string, arg2 bool) error {
+func (m *MockVoter) Vote(arg0 context.Context, arg1 string, arg2 VoteOption) (*VoteResult, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Vote", arg0, arg1, arg2)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(*VoteResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Vote indicates an expected call of Vote.
@@ -77,3 +93,18 @@ func (mr *MockVoterMockRecorder) Vote(arg0, arg1, arg2 interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vote", reflect.TypeOf((*MockVoter)(nil).Vote), arg0, arg1, arg2)
 }
+
+// WeightedVote mocks base method.
+func (m *MockVoter) WeightedVote(arg0 context.Context, arg1 string, arg2 WeightedVoteOptions) (*VoteResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WeightedVote", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*VoteResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WeightedVote indicates an expected call of WeightedVote.
+func (mr *MockVoterMockRecorder) WeightedVote(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WeightedVote", reflect.TypeOf((*MockVoter)(nil).WeightedVote), arg0, arg1, arg2)
+}
@@ -0,0 +1,234 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/app"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMaxAttempts = 5
+	// doubled after each failed attempt
+	defaultInitialBackoff = time.Second * 2
+	maxBackoff            = time.Minute * 5
+	// notifications waiting for delivery
+	queueSize = 1000
+
+	EventHeader     = "X-Cosmos-Voter-Event"
+	DeliveryHeader  = "X-Cosmos-Voter-Delivery"
+	TimestampHeader = "X-Cosmos-Voter-Timestamp"
+	// sha256=hex(hmac_sha256(secret, <timestamp>.<body>))
+	SignatureHeader = "X-Cosmos-Voter-Signature"
+)
+
+// Webhook POSTs notifications as json to every url, failed deliveries are
+// retried with exponential backoff and appended to the dead letter file once
+// attempts are exhausted
+type Webhook struct {
+	urls           []string
+	secret         []byte
+	deadLetterPath string
+	maxAttempts    int
+	initialBackoff time.Duration
+	client         *http.Client
+	queue          chan delivery
+
+	deadLetterMtx sync.Mutex
+}
+
+type delivery struct {
+	id    string
+	event app.NotificationType
+	body  []byte
+}
+
+// deadLetter is a line of the dead letter file
+type deadLetter struct {
+	URL      string          `json:"url"`
+	Delivery string          `json:"delivery"`
+	FailedAt time.Time       `json:"failed_at"`
+	Error    string          `json:"error"`
+	Event    json.RawMessage `json:"event"`
+}
+
+// NewWebhook delivers to urls signing with secret, undeliverable notifications
+// are only logged if deadLetterPath is empty, maxAttempts defaults to 5
+func NewWebhook(urls []string, secret string, deadLetterPath string, maxAttempts int) *Webhook {
+	if maxAttempts < 1 {
+		maxAttempts = defaultMaxAttempts
+	}
+	return &Webhook{
+		urls:           urls,
+		secret:         []byte(secret),
+		deadLetterPath: deadLetterPath,
+		maxAttempts:    maxAttempts,
+		initialBackoff: defaultInitialBackoff,
+		client:         &http.Client{Timeout: time.Second * 15},
+		queue:          make(chan delivery, queueSize),
+	}
+}
+
+// Notify queues notification for Run, it is dead-lettered if the queue is full
+func (w *Webhook) Notify(n app.Notification) {
+	body, err := json.Marshal(n)
+	if err != nil {
+		log.Errorf("failed to encode %s notification: %v", n.Type, err)
+		return
+	}
+	d := delivery{id: newDeliveryID(), event: n.Type, body: body}
+	select {
+	case w.queue <- d:
+	default:
+		for _, url := range w.urls {
+			w.deadLetter(url, d, errors.New("delivery queue is full"))
+		}
+	}
+}
+
+// Run delivers queued notifications until ctx is done, the ones left are dead-lettered
+func (w *Webhook) Run(ctx context.Context) error {
+	for {
+		select {
+		case d := <-w.queue:
+			w.process(ctx, d)
+		case <-ctx.Done():
+			for {
+				select {
+				case d := <-w.queue:
+					for _, url := range w.urls {
+						w.deadLetter(url, d, errors.New("stopped before delivery"))
+					}
+				default:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func (w *Webhook) process(ctx context.Context, d delivery) {
+	for _, url := range w.urls {
+		if err := w.deliver(ctx, url, d); err != nil {
+			log.Errorf("failed to deliver %s notification to %s: %v", d.event, url, err)
+			w.deadLetter(url, d, err)
+		}
+	}
+}
+
+// deliver posts d to url until it is accepted, rejected with a permanent error or attempts are exhausted
+func (w *Webhook) deliver(ctx context.Context, url string, d delivery) error {
+	backoff := w.initialBackoff
+	var err error
+	for attempt := 1; attempt <= w.maxAttempts; attempt++ {
+		var retry bool
+		retry, err = w.post(ctx, url, d)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == w.maxAttempts {
+			break
+		}
+		log.Warnf("%s notification to %s failed, attempt %d/%d, retry in %s: %v", d.event, url, attempt, w.maxAttempts, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return errors.Wrap(err, "stopped before delivery")
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+	return err
+}
+
+// post returns whether a failure is worth retrying, 4xx except 408 and 429 are not
+func (w *Webhook) post(ctx context.Context, url string, d delivery) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(d.body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(d.event))
+	req.Header.Set(DeliveryHeader, d.id)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(w.secret, timestamp, d.body))
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+	err = errors.Errorf("webhook returned status %s", resp.Status)
+	switch {
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests:
+		return true, err
+	case resp.StatusCode/100 == 4:
+		return false, err
+	}
+	return true, err
+}
+
+func (w *Webhook) deadLetter(url string, d delivery, deliveryErr error) {
+	if w.deadLetterPath == "" {
+		log.Errorf("dropped %s notification %s to %s: %v", d.event, d.id, url, deliveryErr)
+		return
+	}
+	line, err := json.Marshal(deadLetter{
+		URL:      url,
+		Delivery: d.id,
+		FailedAt: time.Now().UTC(),
+		Error:    deliveryErr.Error(),
+		Event:    d.body,
+	})
+	if err != nil {
+		log.Errorf("failed to encode dead letter %s: %v", d.id, err)
+		return
+	}
+	w.deadLetterMtx.Lock()
+	defer w.deadLetterMtx.Unlock()
+	file, err := os.OpenFile(w.deadLetterPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Errorf("failed to open dead letter file %s, dropped %s notification %s: %v", w.deadLetterPath, d.event, d.id, err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		log.Errorf("failed to write dead letter file %s, dropped %s notification %s: %v", w.deadLetterPath, d.event, d.id, err)
+	}
+}
+
+// Sign returns the signature header value receivers compare with in constant time
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newDeliveryID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprint(time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id)
+}
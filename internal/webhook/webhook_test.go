@@ -0,0 +1,147 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kostage/cosmos_voter/internal/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSecret = "whsec"
+)
+
+func testWebhook(t *testing.T, urls ...string) (*Webhook, string) {
+	deadLetterPath := filepath.Join(t.TempDir(), "dead_letter.jsonl")
+	hook := NewWebhook(urls, testSecret, deadLetterPath, 3)
+	hook.initialBackoff = time.Millisecond
+	return hook, deadLetterPath
+}
+
+// notifyAndProcess delivers n the way Run does
+func notifyAndProcess(t *testing.T, hook *Webhook, n app.Notification) {
+	hook.Notify(n)
+	require.Len(t, hook.queue, 1)
+	hook.process(context.Background(), <-hook.queue)
+}
+
+func readDeadLetters(t *testing.T, path string) []deadLetter {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	defer file.Close()
+	letters := []deadLetter{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		letter := deadLetter{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &letter))
+		letters = append(letters, letter)
+	}
+	return letters
+}
+
+func TestDeliverSigned(t *testing.T) {
+	received := make(chan app.Notification, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, Sign([]byte(testSecret), r.Header.Get(TimestampHeader), body), r.Header.Get(SignatureHeader))
+		assert.NotEmpty(t, r.Header.Get(DeliveryHeader))
+		n := app.Notification{}
+		require.NoError(t, json.Unmarshal(body, &n))
+		assert.Equal(t, string(n.Type), r.Header.Get(EventHeader))
+		received <- n
+	}))
+	defer server.Close()
+	hook, deadLetterPath := testWebhook(t, server.URL)
+
+	notifyAndProcess(t, hook, app.Notification{
+		Type:       app.VoteConfirmed,
+		ChainID:    "kaiyo-1",
+		ProposalID: "291",
+		Option:     "yes",
+		By:         []string{"kostage"},
+		TxHash:     "ABCD",
+		Height:     100,
+	})
+	n := <-received
+	assert.Equal(t, app.VoteConfirmed, n.Type)
+	assert.Equal(t, "291", n.ProposalID)
+	assert.Equal(t, "ABCD", n.TxHash)
+	assert.Empty(t, readDeadLetters(t, deadLetterPath))
+}
+
+func TestRetryUntilAccepted(t *testing.T) {
+	attempts := atomic.Int64{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+	hook, deadLetterPath := testWebhook(t, server.URL)
+
+	err := hook.deliver(context.Background(), server.URL, delivery{id: "1", event: app.VoteSubmitted, body: []byte("{}")})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), attempts.Load())
+	assert.Empty(t, readDeadLetters(t, deadLetterPath))
+}
+
+func TestDeadLetter(t *testing.T) {
+	attemptsMtx := sync.Mutex{}
+	attempts := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attemptsMtx.Lock()
+		attempts[r.URL.Path]++
+		attemptsMtx.Unlock()
+		if r.URL.Path == "/rejected" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	hook, deadLetterPath := testWebhook(t, server.URL+"/rejected", server.URL+"/down")
+
+	notifyAndProcess(t, hook, app.Notification{Type: app.VoteFailed, ProposalID: "291", Error: "out of gas"})
+	// client errors are not retried
+	attemptsMtx.Lock()
+	assert.Equal(t, 1, attempts["/rejected"])
+	assert.Equal(t, 3, attempts["/down"])
+	attemptsMtx.Unlock()
+	letters := readDeadLetters(t, deadLetterPath)
+	require.Len(t, letters, 2)
+	assert.Equal(t, server.URL+"/rejected", letters[0].URL)
+	assert.Contains(t, letters[0].Error, "401")
+	assert.Equal(t, server.URL+"/down", letters[1].URL)
+	n := app.Notification{}
+	require.NoError(t, json.Unmarshal(letters[1].Event, &n))
+	assert.Equal(t, app.VoteFailed, n.Type)
+	assert.Equal(t, "out of gas", n.Error)
+}
+
+func TestDeadLetterOnStop(t *testing.T) {
+	hook, deadLetterPath := testWebhook(t, "http://127.0.0.1:1")
+	hook.Notify(app.Notification{Type: app.ProposalDetected, ProposalID: "291"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// either taken and stopped before delivery or left in the queue
+	require.NoError(t, hook.Run(ctx))
+	letters := readDeadLetters(t, deadLetterPath)
+	require.Len(t, letters, 1)
+	assert.Contains(t, letters[0].Error, "stopped before delivery")
+}